@@ -34,10 +34,22 @@ type CallFunc func(repo string, ch chan<- string) error
 
 // Exec creates a new CallFunc to execute the given command and arguments,
 // streaming Stdout and Stderr to the channel and returning error status.
+// An empty repository name executes in the current working directory.
 func Exec(command string, arguments ...string) CallFunc {
 	return func(repo string, ch chan<- string) error {
 		cmd := exec.Command(command, arguments...)
-		cmd.Dir = utils.RepoPath(repo)
+		if repo != "" {
+			if err := utils.ValidatePath(repo); err != nil {
+				return err
+			}
+
+			dir, err := utils.RepoPath(repo)
+			if err != nil {
+				return err
+			}
+
+			cmd.Dir = dir
+		}
 
 		// Configure the pipe for stdout
 		pipe, err := cmd.StdoutPipe()
@@ -0,0 +1,83 @@
+package call
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/spf13/viper"
+
+	"github.com/ryclarke/cisco-batch-tool/catalog"
+	"github.com/ryclarke/cisco-batch-tool/config"
+	"github.com/ryclarke/cisco-batch-tool/utils"
+)
+
+// Clone is a CallFunc which clones the repository into the workspace using the
+// configured protocol and clone options. Wrap calls it for missing repositories.
+func Clone(repo string, ch chan<- string) error {
+	path, err := utils.ClonePath(repo)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	args, err := cloneArgs(repo)
+	if err != nil {
+		return err
+	}
+
+	return Exec("git", append(args, catalog.CloneURL(repo), path)...)("", ch)
+}
+
+func cloneArgs(repo string) ([]string, error) {
+	args := []string{"clone", "--progress"}
+
+	switch protocol := viper.GetString(config.CloneProtocol); protocol {
+	case "ssh":
+	case "https":
+		helper, err := credentialHelper()
+		if err != nil {
+			return nil, err
+		}
+
+		// the empty helper resets any helpers inherited from the user's global config
+		args = append(args, "--config", "credential.helper=", "--config", "credential.helper="+helper)
+	default:
+		return nil, fmt.Errorf("unsupported clone protocol %q", protocol)
+	}
+
+	if depth := viper.GetInt(config.CloneDepth); depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(depth))
+	}
+
+	if filter := viper.GetString(config.CloneFilter); filter != "" {
+		args = append(args, "--filter="+filter)
+	}
+
+	if viper.GetBool(config.CloneSingleBranch) {
+		args = append(args, "--single-branch")
+	}
+
+	// reference repositories are looked up by name, and silently skipped if missing
+	if reference := viper.GetString(config.CloneReference); reference != "" {
+		_, _, name := utils.ParseRepo(repo)
+		args = append(args, "--reference-if-able", filepath.Join(reference, name))
+	}
+
+	return args, nil
+}
+
+// credentialHelper returns a git credential helper which calls back into this
+// executable to provide the configured auth token for HTTPS remotes.
+func credentialHelper() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("!'%s' --quiet --offline credential", filepath.ToSlash(exe)), nil
+}
@@ -12,7 +12,8 @@ import (
 
 // Do executes the provided Wrapper on each repository, operating
 // asynchronously by default. Repository aliases are also expanded
-// here to allow for configurable repository grouping.
+// here to allow for configurable repository grouping. The number of
+// repositories processed at once may be limited by configuration.
 func Do(repos []string, fwrap Wrapper) {
 	repos = processArguments(repos)
 
@@ -36,9 +37,7 @@ func Do(repos []string, fwrap Wrapper) {
 	}
 
 	// start asynchronous workers
-	for i, repo := range repos {
-		go fwrap(repo, ch[i])
-	}
+	go start(repos, ch, fwrap)
 
 	// batch and print ordered output
 	for i := range repos {
@@ -48,6 +47,28 @@ func Do(repos []string, fwrap Wrapper) {
 	}
 }
 
+// start executes the Wrapper on each repository, never running more than the configured
+// maximum at once. Workers are started in repository order so that the ordered output
+// never waits on a worker which can't start.
+func start(repos []string, ch []chan string, fwrap Wrapper) {
+	limit := viper.GetInt(config.MaxParallel)
+	if limit <= 0 {
+		limit = len(repos)
+	}
+
+	sem := make(chan struct{}, limit)
+
+	for i, repo := range repos {
+		sem <- struct{}{}
+
+		go func(repo string, ch chan string) {
+			defer func() { <-sem }()
+
+			fwrap(repo, ch)
+		}(repo, ch[i])
+	}
+}
+
 // DoAsync always operates asynchronously regardless of configuration
 func DoAsync(repos []string, fwrap Wrapper) {
 	viper.Set(config.UseSync, false)
@@ -0,0 +1,62 @@
+package call
+
+import (
+	"github.com/spf13/viper"
+
+	"github.com/ryclarke/cisco-batch-tool/config"
+)
+
+// Result holds the outcome of a GatherFunc for a single repository
+type Result[T any] struct {
+	Repo  string
+	Value T
+	Err   error
+}
+
+// GatherFunc collects structured data from a single repository
+type GatherFunc[T any] func(repo string) (T, error)
+
+// Gather executes the provided GatherFunc on each repository with the same
+// repository expansion and concurrency as Do, but returns the results in
+// repository order instead of printing output. This is intended for commands
+// which aggregate data across repositories into a single report. Missing
+// repositories are not cloned.
+func Gather[T any](repos []string, fn GatherFunc[T]) []Result[T] {
+	repos = processArguments(repos)
+	results := make([]Result[T], len(repos))
+
+	index := make(map[string]int, len(repos))
+	for i, repo := range repos {
+		index[repo] = i
+	}
+
+	fwrap := func(repo string, ch chan<- string) {
+		defer close(ch)
+
+		value, err := fn(repo)
+		results[index[repo]] = Result[T]{Repo: repo, Value: value, Err: err}
+	}
+
+	ch := make([]chan string, len(repos))
+	for i := range repos {
+		ch[i] = make(chan string)
+	}
+
+	if viper.GetBool(config.UseSync) {
+		for i, repo := range repos {
+			fwrap(repo, ch[i])
+		}
+
+		return results
+	}
+
+	go start(repos, ch, fwrap)
+
+	// wait for every worker to finish
+	for i := range repos {
+		for range ch[i] {
+		}
+	}
+
+	return results
+}
@@ -22,11 +22,18 @@ func Wrap(calls ...CallFunc) Wrapper {
 
 		ch <- fmt.Sprintf("------ %s ------", repo)
 	
+		path, err := utils.ClonePath(repo)
+		if err != nil {
+			ch <- fmt.Sprintln("ERROR:", err)
+
+			return
+		}
+
 		// if the repository is missing, attempt to clone it first
-		if _, err := os.Stat(utils.RepoPath(repo)); os.IsNotExist(err) {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
 			ch <- "Repository not found, cloning...\n"
 
-			if err = Exec("git", "clone", "--progress", utils.RepoURL(repo))("", ch); err != nil {
+			if err = Clone(repo, ch); err != nil {
 				ch <- fmt.Sprintln("ERROR:", err)
 
 				return
@@ -1,6 +1,7 @@
 package catalog
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -14,14 +15,22 @@ import (
 	"github.com/spf13/viper"
 
 	"github.com/ryclarke/cisco-batch-tool/config"
+	"github.com/ryclarke/cisco-batch-tool/utils"
 )
 
 const (
 	labelKey      = "~"
 	excludeKey    = "!"
 	supersetLabel = "all"
+
+	sourceBitbucket = "bitbucket"
+	sourceScan      = "scan"
+	sourceManifest  = "manifest"
 )
 
+// ScanLocal rebuilds the cached catalog from local clones instead of the remote host
+var ScanLocal bool
+
 // Catalog contains a cached set of repositories and their metadata from Bitbucket
 var Catalog = make(map[string]Repository)
 
@@ -30,7 +39,7 @@ var Labels = make(map[string]mapset.Set[string])
 
 func Init() {
 	if err := initRepositoryCatalog(); err != nil {
-		fmt.Printf("ERROR: Could not load repository metadata: %v", err)
+		logf("ERROR: Could not load repository metadata: %v\n", err)
 	}
 
 	// Add locally-configured aliases to the defined labels
@@ -56,12 +65,20 @@ type Repository struct {
 	Public      bool     `json:"public"`
 	Project     string   `json:"project_name"`
 	Labels      []string `json:"labels,omitempty"`
+	CloneURL    string   `json:"clone_url,omitempty"`
 }
 
-func RepositoryList(filters ...string) mapset.Set[string] {
-	includeSet := mapset.NewSet[string]()
-	excludeSet := mapset.NewSet[string]()
+// CloneURL returns the remote url for the given repository, preferring an
+// explicit url from the catalog source over the configured url template.
+func CloneURL(name string) string {
+	if repo, ok := Catalog[name]; ok && repo.CloneURL != "" {
+		return repo.CloneURL
+	}
 
+	return utils.RepoURL(name)
+}
+
+func RepositoryList(filters ...string) mapset.Set[string] {
 	// Exclude unwanted labels by default
 	if viper.GetBool(config.SkipUnwanted) {
 		for _, unwanted := range viper.GetStringSlice(config.UnwantedLabels) {
@@ -69,6 +86,15 @@ func RepositoryList(filters ...string) mapset.Set[string] {
 		}
 	}
 
+	return RepositoryListAll(filters...)
+}
+
+// RepositoryListAll expands the filters like RepositoryList, but never skips
+// repositories with unwanted labels
+func RepositoryListAll(filters ...string) mapset.Set[string] {
+	includeSet := mapset.NewSet[string]()
+	excludeSet := mapset.NewSet[string]()
+
 	for _, filter := range filters {
 		filterName := strings.ReplaceAll(strings.ReplaceAll(filter, labelKey, ""), excludeKey, "")
 
@@ -99,13 +125,75 @@ func initRepositoryCatalog() error {
 		return nil
 	}
 
-	if err := loadCatalogCache(); err != nil {
-		fmt.Print(err.Error())
+	// Local catalog sources never contact the remote host or use the cache
+	switch source := viper.GetString(config.CatalogSource); source {
+	case sourceScan:
+		return scanLocalRepositories()
+	case sourceManifest:
+		return loadManifest(viper.GetString(config.CatalogManifest))
+	case sourceBitbucket:
+	default:
+		return fmt.Errorf("unknown catalog source %q", source)
+	}
+
+	// Rebuild a minimal catalog from local clones if explicitly requested
+	if ScanLocal {
+		repos, err := localRepositories()
+		if err != nil {
+			return err
+		}
+
+		// the rebuilt catalog is no fresher than the remote info it's based on, if any, and
+		// remote labels can't be found locally, so keep those of the existing cache
+		var updatedAt time.Time
+
+		if cached, err := loadCatalogCache(); err == nil {
+			updatedAt = cached.UpdatedAt
+
+			for name, repo := range repos {
+				repo.Labels = cached.Repositories[name].Labels
+				repos[name] = repo
+			}
+		}
+
+		loadRepositories(repos)
+
+		return saveCatalogCache(updatedAt)
+	}
+
+	cached, err := loadCatalogCache()
+	if err == nil {
+		// Offline mode always uses the cache, regardless of its age
+		if viper.GetBool(config.Offline) || time.Since(cached.UpdatedAt) <= viper.GetDuration(config.CatalogCacheTTL) {
+			loadRepositories(cached.Repositories)
+
+			return nil
+		}
+
+		logf("Local cache of repository catalog is too old - fetching remote info\n")
+	} else if viper.GetBool(config.Offline) {
+		logf("WARNING: %v - scanning local clones instead\n", err)
+
+		return scanLocalRepositories()
 	} else {
-		return nil
+		logf("%v - fetching remote info\n", err)
 	}
 
-	return fetchRepositoryData()
+	if err := fetchRepositoryData(); err != nil {
+		if cached != nil {
+			logf("WARNING: Could not fetch remote info (%v) - using stale cache from %s\n",
+				err, cached.UpdatedAt.Local().Format(time.RFC1123))
+			loadRepositories(cached.Repositories)
+
+			return nil
+		}
+
+		logf("WARNING: Could not fetch remote info (%v) - scanning local clones instead\n", err)
+
+		return scanLocalRepositories()
+	}
+
+	return nil
 }
 
 type repositoryCache struct {
@@ -123,26 +211,14 @@ type labelListResp struct {
 	} `json:"values"`
 }
 
-func loadCatalogCache() error {
-	file, err := os.Open(catalogCachePath())
-	if err != nil {
-		return fmt.Errorf("local cache of repository catalog is missing or invalid - fetching remote info")
-	}
-
-	defer file.Close()
-
-	var cached repositoryCache
-	if err := json.NewDecoder(file).Decode(&cached); err != nil {
-		return err
-	}
-
-	if time.Since(cached.UpdatedAt) > viper.GetDuration(config.CatalogCacheTTL) {
-		return fmt.Errorf("local cache of repository catalog is too old - fetching remote info")
-	}
-
-	Catalog = cached.Repositories
+// loadRepositories replaces the catalog with the given repositories and indexes their labels
+func loadRepositories(repos map[string]Repository) {
+	Catalog = repos
 
 	for _, repo := range Catalog {
+		// repositories outside the configured project are located by their own project
+		utils.SetRepoProject(repo.Name, repo.Project)
+
 		for _, label := range repo.Labels {
 			if _, ok := Labels[label]; !ok {
 				Labels[label] = mapset.NewSet[string](repo.Name)
@@ -151,13 +227,32 @@ func loadCatalogCache() error {
 			}
 		}
 	}
+}
 
-	return nil
+func loadCatalogCache() (*repositoryCache, error) {
+	path, err := catalogCachePath()
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("local cache of repository catalog is missing or invalid")
+	}
+
+	defer file.Close()
+
+	var cached repositoryCache
+	if err := json.NewDecoder(file).Decode(&cached); err != nil {
+		return nil, err
+	}
+
+	return &cached, nil
 }
 
-func saveCatalogCache() error {
+func saveCatalogCache(updatedAt time.Time) error {
 	cache := repositoryCache{
-		UpdatedAt:    time.Now().UTC(),
+		UpdatedAt:    updatedAt,
 		Repositories: Catalog,
 	}
 
@@ -166,7 +261,16 @@ func saveCatalogCache() error {
 		return err
 	}
 
-	return os.WriteFile(catalogCachePath(), data, 0644)
+	path, err := catalogCachePath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
 }
 
 func fetchRepositoryData() error {
@@ -182,6 +286,9 @@ func fetchRepositoryData() error {
 		return err
 	}
 
+	// Collect everything before touching the catalog so a failure can't leave it half-populated
+	repos := make(map[string]Repository, len(resp.Values))
+
 	for _, repo := range resp.Values {
 		repo.Project = project
 
@@ -192,30 +299,25 @@ func fetchRepositoryData() error {
 
 		repo.Labels = labels
 
-		Catalog[repo.Name] = repo
-
-		for _, label := range repo.Labels {
-			if _, ok := Labels[label]; !ok {
-				Labels[label] = mapset.NewSet[string](repo.Name)
-			} else {
-				Labels[label].Add(repo.Name)
-			}
-		}
+		repos[repo.Name] = repo
 	}
 
-	return saveCatalogCache()
+	loadRepositories(repos)
+
+	return saveCatalogCache(time.Now().UTC())
 }
 
-func catalogCachePath() string {
-	return filepath.Join(viper.GetString(config.EnvGopath), "src",
-		viper.GetString(config.GitHost),
-		viper.GetString(config.GitProject),
-		viper.GetString(config.CatalogCacheFile),
-	)
+func catalogCachePath() (string, error) {
+	dir, err := utils.ProjectPath(viper.GetString(config.GitProject))
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, viper.GetString(config.CatalogCacheFile)), nil
 }
 
 func getLabels(project, repo string) ([]string, error) {
-	output, err := apiGET(fmt.Sprintf("https://%s/rest/api/1.0/projects/%s/repos/%s/labels?limit=100", viper.GetString(config.GitHost), project, repo))
+	output, err := apiGET(labelsPath(project, repo) + "?limit=100")
 	if err != nil {
 		return nil, err
 	}
@@ -234,15 +336,41 @@ func getLabels(project, repo string) ([]string, error) {
 	return labels, nil
 }
 
+func labelsPath(project, repo string) string {
+	return fmt.Sprintf("https://%s/rest/api/1.0/projects/%s/repos/%s/labels", viper.GetString(config.GitHost), project, repo)
+}
+
+// logf prints catalog status messages, which are sent to stderr in quiet mode
+func logf(format string, args ...interface{}) {
+	if viper.GetBool(config.Quiet) {
+		fmt.Fprintf(os.Stderr, format, args...)
+		return
+	}
+
+	fmt.Printf(format, args...)
+}
+
+// apiClient gives up quickly if the remote host is unreachable (e.g. without a VPN
+// connection), so that falling back to the cache doesn't stall every command
+var apiClient = &http.Client{Timeout: 10 * time.Second}
+
 func apiGET(path string) ([]byte, error) {
-	request, err := http.NewRequest(http.MethodGet, path, nil)
+	return apiRequest(http.MethodGet, path, nil)
+}
+
+func apiRequest(method, path string, body []byte) ([]byte, error) {
+	request, err := http.NewRequest(method, path, bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
 
 	request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", viper.GetString(config.AuthToken)))
 
-	resp, err := http.DefaultClient.Do(request)
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := apiClient.Do(request)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,122 @@
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/spf13/viper"
+
+	"github.com/ryclarke/cisco-batch-tool/config"
+)
+
+// HasLabel returns true if the repository carries the given remote label. Local
+// aliases are not considered.
+func HasLabel(repo, label string) bool {
+	for _, l := range Catalog[repo].Labels {
+		if l == label {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AddLabel creates the label on the remote repository and records it in the catalog
+func AddLabel(repo, label string) error {
+	if _, ok := Catalog[repo]; !ok {
+		return fmt.Errorf("repository %s is not in the catalog", repo)
+	}
+
+	if HasLabel(repo, label) {
+		return nil
+	}
+
+	if err := validateRemote(); err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(map[string]string{"name": label})
+	if err != nil {
+		return err
+	}
+
+	if _, err := apiRequest(http.MethodPost, labelsPath(repoProject(repo), repo), payload); err != nil {
+		return err
+	}
+
+	entry := Catalog[repo]
+	entry.Labels = append(entry.Labels, label)
+	Catalog[repo] = entry
+
+	if _, ok := Labels[label]; !ok {
+		Labels[label] = mapset.NewSet[string](repo)
+	} else {
+		Labels[label].Add(repo)
+	}
+
+	return nil
+}
+
+// RemoveLabel deletes the label from the remote repository and from the catalog
+func RemoveLabel(repo, label string) error {
+	if _, ok := Catalog[repo]; !ok {
+		return fmt.Errorf("repository %s is not in the catalog", repo)
+	}
+
+	if !HasLabel(repo, label) {
+		return nil
+	}
+
+	if err := validateRemote(); err != nil {
+		return err
+	}
+
+	if _, err := apiRequest(http.MethodDelete, labelsPath(repoProject(repo), repo)+"/"+url.PathEscape(label), nil); err != nil {
+		return err
+	}
+
+	entry := Catalog[repo]
+	labels := make([]string, 0, len(entry.Labels))
+
+	for _, l := range entry.Labels {
+		if l != label {
+			labels = append(labels, l)
+		}
+	}
+
+	entry.Labels = labels
+	Catalog[repo] = entry
+
+	if set, ok := Labels[label]; ok {
+		set.Remove(repo)
+	}
+
+	return nil
+}
+
+// SaveCache writes the current catalog to the local cache
+func SaveCache() error {
+	return saveCatalogCache(time.Now().UTC())
+}
+
+// repoProject returns the project of a catalog repository, or the configured project
+func repoProject(repo string) string {
+	if entry, ok := Catalog[repo]; ok && entry.Project != "" {
+		return entry.Project
+	}
+
+	return viper.GetString(config.GitProject)
+}
+
+// validateRemote returns an error if the remote host must not be contacted
+func validateRemote() error {
+	if viper.GetBool(config.Offline) {
+		return fmt.Errorf("remote labels cannot be modified in offline mode")
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package catalog
+
+import (
+	"github.com/spf13/viper"
+
+	"github.com/ryclarke/cisco-batch-tool/config"
+	"github.com/ryclarke/cisco-batch-tool/utils"
+)
+
+// scanLocalRepositories builds a minimal catalog from the git repositories already
+// cloned into the workspace. No descriptions or remote labels are available, but
+// locally-configured aliases still apply. Clones of other projects are named in the
+// form `<project>/<name>` if their name is already taken.
+func scanLocalRepositories() error {
+	repos, err := localRepositories()
+	if err != nil {
+		return err
+	}
+
+	loadRepositories(repos)
+
+	return nil
+}
+
+// localRepositories returns the repositories cloned into the workspace
+func localRepositories() (map[string]Repository, error) {
+	clones, err := utils.WorkspaceClones()
+	if err != nil {
+		return nil, err
+	}
+
+	project := viper.GetString(config.GitProject)
+	repos := make(map[string]Repository, len(clones))
+
+	// clones of the configured project take precedence over the plain name
+	for _, clone := range clones {
+		if clone.Project == project {
+			repos[clone.Name] = Repository{Name: clone.Name, Project: clone.Project}
+		}
+	}
+
+	for _, clone := range clones {
+		if clone.Project == project {
+			continue
+		}
+
+		name := clone.Name
+		if _, ok := repos[name]; ok {
+			name = clone.Project + "/" + clone.Name
+		}
+
+		repos[name] = Repository{Name: name, Project: clone.Project}
+	}
+
+	return repos, nil
+}
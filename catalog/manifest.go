@@ -0,0 +1,123 @@
+package catalog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+
+	"github.com/ryclarke/cisco-batch-tool/config"
+)
+
+// manifest describes a checked-in list of repositories, similar to a repo tool
+// manifest. Remotes provide the base fetch url for repositories which use them,
+// and groups are applied to their member repositories as labels.
+//
+// Example:
+//
+//	remotes:
+//	  origin:
+//	    fetch: ssh://git@git.example.com/project
+//	default-remote: origin
+//	repos:
+//	  - name: service-a
+//	    labels: [backend]
+//	  - name: tool
+//	    project: other
+//	    remote: origin
+//	groups:
+//	  release: [service-a, tool]
+type manifest struct {
+	Remotes       map[string]manifestRemote `yaml:"remotes"`
+	DefaultRemote string                    `yaml:"default-remote"`
+	Repos         []manifestRepo            `yaml:"repos"`
+	Groups        map[string][]string       `yaml:"groups"`
+}
+
+type manifestRemote struct {
+	Fetch string `yaml:"fetch"`
+}
+
+type manifestRepo struct {
+	Name        string   `yaml:"name"`
+	Description string   `yaml:"description"`
+	Project     string   `yaml:"project"`
+	Remote      string   `yaml:"remote"`
+	URL         string   `yaml:"url"`
+	Labels      []string `yaml:"labels"`
+}
+
+// loadManifest builds the catalog from the manifest file at the given path. Relative
+// paths are resolved against the directory of the config file in use, if any.
+func loadManifest(path string) error {
+	if !filepath.IsAbs(path) && viper.ConfigFileUsed() != "" {
+		path = filepath.Join(filepath.Dir(viper.ConfigFileUsed()), path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("could not read manifest: %w", err)
+	}
+
+	// names are case-sensitive and may contain dots, so the manifest isn't read with viper
+	var m manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return fmt.Errorf("invalid manifest %s: %w", path, err)
+	}
+
+	repos := make(map[string]Repository, len(m.Repos))
+
+	for _, entry := range m.Repos {
+		if entry.Name == "" {
+			return fmt.Errorf("invalid manifest %s: repository name is required", path)
+		}
+
+		repo := Repository{
+			Name:        entry.Name,
+			Description: entry.Description,
+			Project:     entry.Project,
+			Labels:      entry.Labels,
+			CloneURL:    entry.URL,
+		}
+
+		if repo.Project == "" {
+			repo.Project = viper.GetString(config.GitProject)
+		}
+
+		remote := entry.Remote
+		if remote == "" {
+			remote = m.DefaultRemote
+		}
+
+		if repo.CloneURL == "" && remote != "" {
+			r, ok := m.Remotes[remote]
+			if !ok {
+				return fmt.Errorf("invalid manifest %s: unknown remote %q for %s", path, remote, repo.Name)
+			}
+
+			repo.CloneURL = fmt.Sprintf("%s/%s.git", strings.TrimSuffix(r.Fetch, "/"), repo.Name)
+		}
+
+		repos[repo.Name] = repo
+	}
+
+	// groups are flattened into the labels of each member repository
+	for group, members := range m.Groups {
+		for _, name := range members {
+			repo, ok := repos[name]
+			if !ok {
+				return fmt.Errorf("invalid manifest %s: unknown repository %q in group %s", path, name, group)
+			}
+
+			repo.Labels = append(repo.Labels, group)
+			repos[name] = repo
+		}
+	}
+
+	loadRepositories(repos)
+
+	return nil
+}
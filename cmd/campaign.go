@@ -0,0 +1,662 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+
+	"github.com/ryclarke/cisco-batch-tool/call"
+	"github.com/ryclarke/cisco-batch-tool/cmd/git"
+	"github.com/ryclarke/cisco-batch-tool/cmd/pr"
+	"github.com/ryclarke/cisco-batch-tool/config"
+	"github.com/ryclarke/cisco-batch-tool/utils"
+)
+
+// Campaign repository states
+const (
+	stateNoChange  = "no-change"
+	stateCommitted = "committed"
+	statePROpen    = "pr-open"
+	stateApproved  = "approved"
+	stateMerged    = "merged"
+	stateDeclined  = "declined"
+	stateFailed    = "failed"
+)
+
+func addCampaignCmd() *cobra.Command {
+	// campaignCmd represents the campaign command
+	campaignCmd := &cobra.Command{
+		Use:   "campaign",
+		Short: "Apply and track batch changes described by a campaign spec",
+		Long: `Apply and track batch changes described by a campaign spec
+
+A campaign spec is a YAML file describing a change across repositories: the
+repositories (or labels) to change, the branch name, the steps which modify each
+repository, the commit message, and the pull request to submit. The state of each
+repository is recorded in a state file next to the spec (or at '--state'), so the
+campaign can be re-applied after failures and its progress reported later.
+
+Example:
+
+  name: bump-base-image
+  repos: [~docker]
+  branch: bump-base-image
+  steps:
+    - replace:
+        search: 'FROM alpine:3.18'
+        with: 'FROM alpine:3.19'
+        glob: [Dockerfile]
+    - run: make generate
+  commit: Bump base image to alpine 3.19
+  pr:
+    title: Bump base image to alpine 3.19
+    description: Part of the fleet-wide base image upgrade.
+    reviewers: [jdoe]`,
+	}
+
+	campaignCmd.PersistentFlags().String("state", "", "campaign state file (default is <spec>.state.json)")
+
+	campaignCmd.AddCommand(
+		addCampaignApplyCmd(),
+		addCampaignStatusCmd(),
+	)
+
+	return campaignCmd
+}
+
+func addCampaignApplyCmd() *cobra.Command {
+	// campaignApplyCmd represents the campaign apply command
+	campaignApplyCmd := &cobra.Command{
+		Use:   "apply <spec>",
+		Short: "Apply a campaign to its repositories",
+		Long: `Apply a campaign to its repositories
+
+Each repository is updated from the source branch and the campaign branch is
+checked out, then the steps are run and any changes are committed, pushed and
+submitted as a pull request. Repositories without any changes are recorded as
+such and their campaign branch is removed. Repositories which already have an open
+or merged pull request are skipped, so apply may be repeated to retry failures.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			spec, err := loadCampaign(args[0])
+			if err != nil {
+				return err
+			}
+
+			if spec.PR != nil {
+				if err := utils.ValidateRequiredConfig(config.AuthToken); err != nil {
+					return err
+				}
+			}
+
+			statePath, err := campaignStatePath(cmd, args[0])
+			if err != nil {
+				return err
+			}
+
+			state, err := loadCampaignState(statePath, spec)
+			if err != nil {
+				return err
+			}
+
+			var repos []string
+
+			for _, repo := range selectRepos(spec.Repos) {
+				switch state.Repos[repo].State {
+				case statePROpen, stateApproved, stateMerged:
+					fmt.Printf("Skipping %s: %s\n", repo, state.Repos[repo].State)
+				default:
+					repos = append(repos, repo)
+				}
+			}
+
+			if len(repos) == 0 {
+				fmt.Println("No repositories to apply")
+				return nil
+			}
+
+			viper.Set(config.Branch, spec.Branch)
+			viper.Set(config.CommitMessage, spec.Commit)
+
+			if spec.PR != nil && len(spec.PR.Reviewers) > 0 {
+				viper.Set(config.Reviewers, spec.PR.Reviewers)
+			}
+
+			// repositories which fail before the campaign is applied (e.g. clone errors) are not recorded otherwise
+			for _, repo := range repos {
+				state.set(repo, campaignEntry{State: stateFailed, Error: "campaign was not applied"})
+			}
+
+			call.Do(repos, call.Wrap(state.record(applyCampaign(spec))))
+
+			if err := saveCampaignState(statePath, state); err != nil {
+				return err
+			}
+
+			var failed int
+
+			for _, repo := range repos {
+				if state.Repos[repo].State == stateFailed {
+					failed++
+				}
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("campaign failed for %d of %d repositories", failed, len(repos))
+			}
+
+			return nil
+		},
+	}
+
+	return campaignApplyCmd
+}
+
+func addCampaignStatusCmd() *cobra.Command {
+	// campaignStatusCmd represents the campaign status command
+	campaignStatusCmd := &cobra.Command{
+		Use:   "status <spec>",
+		Short: "Report the state of a campaign",
+		Long: `Report the state of a campaign
+
+The recorded state of each repository is refreshed from its pull request (if an
+auth token is available) and saved, then summarized in a table.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			spec, err := loadCampaign(args[0])
+			if err != nil {
+				return err
+			}
+
+			statePath, err := campaignStatePath(cmd, args[0])
+			if err != nil {
+				return err
+			}
+
+			state, err := loadCampaignState(statePath, spec)
+			if err != nil {
+				return err
+			}
+
+			if viper.GetString(config.AuthToken) == "" {
+				fmt.Println("WARNING: no auth token, pull request states were not refreshed")
+			} else {
+				refreshCampaignState(spec, state)
+
+				if err := saveCampaignState(statePath, state); err != nil {
+					return err
+				}
+			}
+
+			if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+				output, err := json.MarshalIndent(state, "", "  ")
+				if err != nil {
+					return err
+				}
+
+				fmt.Println(string(output))
+
+				return nil
+			}
+
+			printCampaignState(state)
+
+			return nil
+		},
+	}
+
+	campaignStatusCmd.Flags().Bool("json", false, "print the campaign state as JSON")
+
+	return campaignStatusCmd
+}
+
+// campaignSpec describes a batch change across repositories
+type campaignSpec struct {
+	Name   string         `yaml:"name"`
+	Repos  []string       `yaml:"repos"`
+	Branch string         `yaml:"branch"`
+	Steps  []campaignStep `yaml:"steps"`
+	Commit string         `yaml:"commit"`
+	PR     *campaignPR    `yaml:"pr"`
+}
+
+// campaignStep modifies a repository, either by running a shell command in the
+// repository or by replacing text in its tracked files
+type campaignStep struct {
+	Run     string           `yaml:"run"`
+	Replace *campaignReplace `yaml:"replace"`
+}
+
+type campaignReplace struct {
+	Search string   `yaml:"search"`
+	With   string   `yaml:"with"`
+	Regex  bool     `yaml:"regex"`
+	Glob   []string `yaml:"glob"`
+}
+
+type campaignPR struct {
+	Title        string   `yaml:"title"`
+	Description  string   `yaml:"description"`
+	Reviewers    []string `yaml:"reviewers"`
+	AllReviewers bool     `yaml:"all-reviewers"`
+}
+
+func loadCampaign(path string) (*campaignSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read campaign: %w", err)
+	}
+
+	// values such as search strings are case-sensitive, so the spec isn't read through viper
+	var spec campaignSpec
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("invalid campaign %s: %w", path, err)
+	}
+
+	if spec.Name == "" {
+		spec.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	switch {
+	case len(spec.Repos) == 0:
+		return nil, fmt.Errorf("invalid campaign %s: repos are required", path)
+	case spec.Branch == "":
+		return nil, fmt.Errorf("invalid campaign %s: branch is required", path)
+	case spec.Commit == "":
+		return nil, fmt.Errorf("invalid campaign %s: commit message is required", path)
+	case len(spec.Steps) == 0:
+		return nil, fmt.Errorf("invalid campaign %s: at least one step is required", path)
+	}
+
+	for i, step := range spec.Steps {
+		if (step.Run == "") == (step.Replace == nil) {
+			return nil, fmt.Errorf("invalid campaign %s: step %d must have exactly one of run or replace", path, i+1)
+		}
+
+		if step.Replace != nil && step.Replace.Regex {
+			if _, err := regexp.Compile(step.Replace.Search); err != nil {
+				return nil, fmt.Errorf("invalid campaign %s: step %d: %w", path, i+1, err)
+			}
+		}
+	}
+
+	if err := exec.Command("git", "check-ref-format", "--branch", spec.Branch).Run(); err != nil {
+		return nil, fmt.Errorf("invalid campaign %s: invalid branch name %s", path, spec.Branch)
+	}
+
+	return &spec, nil
+}
+
+// campaignState is the persisted state of a campaign
+type campaignState struct {
+	Name   string                   `json:"name"`
+	Branch string                   `json:"branch"`
+	Repos  map[string]campaignEntry `json:"repos"`
+
+	mu sync.Mutex
+}
+
+// campaignEntry is the state of a single repository of a campaign
+type campaignEntry struct {
+	State       string    `json:"state"`
+	PullRequest int       `json:"pull_request,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	Updated     time.Time `json:"updated"`
+}
+
+func campaignStatePath(cmd *cobra.Command, spec string) (string, error) {
+	path, err := cmd.Flags().GetString("state")
+	if err != nil || path != "" {
+		return path, err
+	}
+
+	return strings.TrimSuffix(spec, filepath.Ext(spec)) + ".state.json", nil
+}
+
+// loadCampaignState reads the campaign state file, or returns an empty state if it doesn't exist yet
+func loadCampaignState(path string, spec *campaignSpec) (*campaignState, error) {
+	state := &campaignState{Name: spec.Name, Branch: spec.Branch, Repos: make(map[string]campaignEntry)}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("invalid campaign state %s: %w", path, err)
+	}
+
+	if state.Branch != spec.Branch {
+		return nil, fmt.Errorf("campaign state %s is for branch %s, not %s", path, state.Branch, spec.Branch)
+	}
+
+	return state, nil
+}
+
+func saveCampaignState(path string, state *campaignState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+func (s *campaignState) set(repo string, entry campaignEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry.Updated = time.Now().UTC()
+	s.Repos[repo] = entry
+}
+
+// record wraps the campaign CallFunc to record its outcome for each repository
+func (s *campaignState) record(apply func(string, chan<- string) (campaignEntry, error)) call.CallFunc {
+	return func(repo string, ch chan<- string) error {
+		entry, err := apply(repo, ch)
+		if err != nil {
+			entry.State = stateFailed
+			entry.Error = err.Error()
+		}
+
+		s.set(repo, entry)
+
+		return err
+	}
+}
+
+// applyCampaign returns a function which applies the campaign to a single repository
+func applyCampaign(spec *campaignSpec) func(string, chan<- string) (campaignEntry, error) {
+	return func(repo string, ch chan<- string) (campaignEntry, error) {
+		var entry campaignEntry
+
+		if err := utils.ValidatePath(repo); err != nil {
+			return entry, err
+		}
+
+		if err := git.Update(repo, ch); err != nil {
+			return entry, err
+		}
+
+		if err := git.Checkout(repo, ch); err != nil {
+			return entry, err
+		}
+
+		for _, step := range spec.Steps {
+			if err := runCampaignStep(repo, step, ch); err != nil {
+				return entry, err
+			}
+		}
+
+		dir, err := utils.RepoPath(repo)
+		if err != nil {
+			return entry, err
+		}
+
+		dirty, err := utils.GitOutput(dir, "status", "--porcelain")
+		if err != nil {
+			return entry, err
+		}
+
+		ahead, err := utils.GitOutput(dir, "rev-list", "--count", "origin/"+viper.GetString(config.SourceBranch)+"..HEAD")
+		if err != nil {
+			return entry, err
+		}
+
+		if dirty == "" && ahead == "0" {
+			ch <- "No changes, removing the campaign branch\n"
+			entry.State = stateNoChange
+
+			return entry, removeCampaignBranch(repo, spec.Branch)
+		}
+
+		// a previous apply may have committed the changes already
+		if dirty != "" {
+			if err := git.Commit(repo, ch); err != nil {
+				return entry, err
+			}
+		}
+
+		// ... but failed to push them, or the remote branch may be missing altogether
+		unpushed, err := utils.GitOutput(dir, "rev-list", "--count", "origin/"+spec.Branch+"..HEAD")
+		if err != nil || unpushed != "0" {
+			cmd := exec.Command("git", "push", "-u", "origin", spec.Branch)
+			cmd.Dir = dir
+
+			output, err := cmd.CombinedOutput()
+			if err != nil {
+				return entry, fmt.Errorf("%w: %s", err, output)
+			}
+
+			ch <- string(output)
+		}
+
+		entry.State = stateCommitted
+
+		if spec.PR == nil {
+			return entry, nil
+		}
+
+		existing, err := utils.GetPR(repo, spec.Branch)
+		if err != nil {
+			return entry, err
+		}
+
+		if existing == nil {
+			title := spec.PR.Title
+			if title == "" {
+				title = spec.Name
+			}
+
+			if err := pr.New(title, spec.PR.Description, spec.PR.AllReviewers)(repo, ch); err != nil {
+				return entry, err
+			}
+
+			if existing, err = utils.GetPR(repo, spec.Branch); err != nil || existing == nil {
+				return entry, err
+			}
+		}
+
+		entry.State = statePROpen
+		entry.PullRequest = existing.ID()
+
+		return entry, nil
+	}
+}
+
+func runCampaignStep(repo string, step campaignStep, ch chan<- string) error {
+	dir, err := utils.RepoPath(repo)
+	if err != nil {
+		return err
+	}
+
+	if step.Run != "" {
+		cmd := exec.Command("sh", "-c", step.Run)
+		cmd.Dir = dir
+
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("%w: %s", err, output)
+		}
+
+		ch <- string(output)
+
+		return nil
+	}
+
+	search, with := []byte(step.Replace.Search), []byte(step.Replace.With)
+
+	replacer := func(content []byte) []byte {
+		return bytes.ReplaceAll(content, search, with)
+	}
+
+	// the expression is validated when loading the campaign
+	if step.Replace.Regex {
+		re := regexp.MustCompile(step.Replace.Search)
+
+		replacer = func(content []byte) []byte {
+			return re.ReplaceAll(content, with)
+		}
+	}
+
+	changes, err := planReplace(repo, step.Replace.Glob, replacer)
+	if err != nil {
+		return err
+	}
+
+	if err := writeChanges(repo, changes); err != nil {
+		return err
+	}
+
+	return printChanges(changes, ch)
+}
+
+// removeCampaignBranch switches back to the source branch and deletes the unchanged
+// campaign branch, both locally and on the remote. A campaign branch which was never
+// pushed is already removed from the remote.
+func removeCampaignBranch(repo, branch string) error {
+	dir, err := utils.RepoPath(repo)
+	if err != nil {
+		return err
+	}
+
+	if _, err := utils.GitOutput(dir, "checkout", viper.GetString(config.SourceBranch)); err != nil {
+		return err
+	}
+
+	if _, err := utils.GitOutput(dir, "branch", "-D", branch); err != nil {
+		return err
+	}
+
+	// ls-remote exits with status 2 when no matching refs are found
+	cmd := exec.Command("git", "ls-remote", "--exit-code", "--heads", "origin", "refs/heads/"+branch)
+	cmd.Dir = dir
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 2 {
+			return nil
+		}
+
+		return fmt.Errorf("%w: %s", err, output)
+	}
+
+	cmd = exec.Command("git", "push", "origin", "--delete", branch)
+	cmd.Dir = dir
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, output)
+	}
+
+	return nil
+}
+
+// refreshCampaignState updates the state of each repository from its pull request
+func refreshCampaignState(spec *campaignSpec, state *campaignState) {
+	var repos []string
+
+	for repo, entry := range state.Repos {
+		if entry.PullRequest != 0 || (entry.State == stateCommitted && spec.PR != nil) {
+			repos = append(repos, repo)
+		}
+	}
+
+	results := call.Gather(repos, func(repo string) (utils.PR, error) {
+		prs, err := utils.GetPRs(repo, spec.Branch, "ALL")
+		if err != nil || len(prs) == 0 {
+			return nil, err
+		}
+
+		return prs[0], nil
+	})
+
+	for _, result := range results {
+		entry := state.Repos[result.Repo]
+
+		switch {
+		case result.Err != nil:
+			fmt.Printf("WARNING: %s: could not refresh pull request: %v\n", result.Repo, result.Err)
+			continue
+		case result.Value == nil:
+			continue
+		}
+
+		entry.PullRequest = result.Value.ID()
+
+		switch result.Value.State() {
+		case "MERGED":
+			entry.State = stateMerged
+		case "DECLINED":
+			entry.State = stateDeclined
+		default:
+			entry.State = statePROpen
+			if result.Value.Approved() {
+				entry.State = stateApproved
+			}
+		}
+
+		if entry.State != state.Repos[result.Repo].State {
+			state.set(result.Repo, entry)
+		}
+	}
+}
+
+func printCampaignState(state *campaignState) {
+	repos := make([]string, 0, len(state.Repos))
+	counts := make(map[string]int)
+
+	for repo, entry := range state.Repos {
+		repos = append(repos, repo)
+		counts[entry.State]++
+	}
+
+	sort.Strings(repos)
+
+	fmt.Printf("Campaign %s (branch %s)\n\n", state.Name, state.Branch)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "REPOSITORY\tSTATE\tPR\tUPDATED")
+
+	for _, repo := range repos {
+		entry := state.Repos[repo]
+
+		pr := "-"
+		if entry.PullRequest != 0 {
+			pr = fmt.Sprintf("#%d", entry.PullRequest)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", repo, entry.State, pr, entry.Updated.Local().Format("2006-01-02 15:04"))
+	}
+
+	w.Flush()
+
+	for _, repo := range repos {
+		if entry := state.Repos[repo]; entry.Error != "" {
+			fmt.Printf("ERROR: %s: %s\n", repo, entry.Error)
+		}
+	}
+
+	fmt.Println()
+
+	for _, name := range []string{stateNoChange, stateCommitted, statePROpen, stateApproved, stateMerged, stateDeclined, stateFailed} {
+		if counts[name] > 0 {
+			fmt.Printf("%s: %d\n", name, counts[name])
+		}
+	}
+}
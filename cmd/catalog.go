@@ -8,11 +8,32 @@ import (
 	"github.com/ryclarke/cisco-batch-tool/catalog"
 )
 
+func addCatalogCmd() *cobra.Command {
+	// catalogCmd represents the catalog command
+	catalogCmd := &cobra.Command{
+		Use:   "catalog",
+		Short: "Print information on the cached repository catalog",
+		Long: `Print information on the cached repository catalog
+
+The catalog is cached locally and refreshed from the remote host once the cache
+expires. If the remote host is unreachable the stale cache is used instead, and
+if no cache exists a minimal catalog is built from the repositories already
+cloned on disk. Use '--scan-local' to rebuild the cache from local clones.`,
+		Run: func(_ *cobra.Command, _ []string) {
+			fmt.Printf("%v\n", catalog.Catalog)
+		},
+	}
+
+	catalogCmd.Flags().BoolVar(&catalog.ScanLocal, "scan-local", false, "rebuild the cached catalog from local clones")
+
+	return catalogCmd
+}
+
 func addLabelsCmd() *cobra.Command {
 	// labelsCmd represents the labels command
 	labelsCmd := &cobra.Command{
 		Use:   "labels <repository|label> ...",
-		Short: "Inspect repository labels and test filters",
+		Short: "Inspect and manage repository labels and test filters",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Import command(s) from the CLI flag
 			verbose, err := cmd.Flags().GetBool("verbose")
@@ -31,6 +52,12 @@ func addLabelsCmd() *cobra.Command {
 		},
 	}
 
+	labelsCmd.AddCommand(
+		addLabelsAddCmd(),
+		addLabelsRemoveCmd(),
+		addLabelsSyncCmd(),
+	)
+
 	labelsCmd.Flags().BoolP("verbose", "v", false, "expand labels referenced in the given filter")
 
 	return labelsCmd
@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/ryclarke/cisco-batch-tool/call"
+	"github.com/ryclarke/cisco-batch-tool/cmd/git"
+	"github.com/ryclarke/cisco-batch-tool/cmd/pr"
+	"github.com/ryclarke/cisco-batch-tool/config"
+	"github.com/ryclarke/cisco-batch-tool/utils"
+)
+
+// flags shared by commands which modify files and carry the change through the
+// branch, commit and pull request flow
+var (
+	changeDryRun       bool
+	changeBranch       string
+	changeMessage      string
+	changePR           bool
+	changeTitle        string
+	changeDescription  string
+	changeAllReviewers bool
+)
+
+// fileChange is the original and modified content of a single file, where the original
+// content is nil if the file is created
+type fileChange struct {
+	path string
+	mode os.FileMode
+	old  []byte
+	new  []byte
+}
+
+// planFunc computes the file changes for a repository without modifying it
+type planFunc func(repo string) ([]fileChange, error)
+
+func addChangeFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&changeDryRun, "dry-run", false, "show the changes without modifying any files")
+	cmd.Flags().StringVarP(&changeBranch, "branch", "b", "", "check out a new branch before making changes")
+	cmd.Flags().StringVarP(&changeMessage, "message", "m", "", "commit and push the changes with this message")
+	cmd.Flags().BoolVar(&changePR, "pr", false, "submit a new pull request for the changes (requires '--message')")
+	cmd.Flags().StringVarP(&changeTitle, "title", "t", "", "pull request title")
+	cmd.Flags().StringVarP(&changeDescription, "description", "d", "", "pull request description")
+	cmd.Flags().StringSliceP("reviewer", "r", nil, "pull request reviewer (cecid)")
+	cmd.Flags().BoolVarP(&changeAllReviewers, "all-reviewers", "a", false, "use all provided reviewers for a new PR")
+}
+
+// validateChangeFlags requires a commit message and an auth token to submit pull requests
+func validateChangeFlags(_ *cobra.Command, _ []string) error {
+	if !changePR {
+		return nil
+	}
+
+	if changeMessage == "" {
+		return fmt.Errorf("a commit message is required to submit pull requests")
+	}
+
+	return utils.ValidateRequiredConfig(config.AuthToken)
+}
+
+// setChangeConfig applies the change flags to the configuration used by the git and pr commands
+func setChangeConfig(cmd *cobra.Command) {
+	if changeBranch != "" {
+		viper.Set(config.Branch, changeBranch)
+	}
+
+	if changeMessage != "" {
+		viper.Set(config.CommitMessage, changeMessage)
+	}
+
+	if cmd.Flags().Changed("reviewer") {
+		reviewers, _ := cmd.Flags().GetStringSlice("reviewer")
+		viper.Set(config.Reviewers, reviewers)
+	}
+}
+
+// applyChange returns a CallFunc which applies the planned file changes to a repository,
+// then carries the change through the branch, commit and pull request flow as configured.
+func applyChange(plan planFunc) call.CallFunc {
+	return func(repo string, ch chan<- string) error {
+		var changes []fileChange
+
+		return carryChange(repo, ch, func() (bool, error) {
+			var err error
+			if changes, err = plan(repo); err != nil {
+				return false, err
+			}
+
+			if len(changes) == 0 {
+				ch <- "No changes\n"
+				return false, nil
+			}
+
+			return true, nil
+		}, func() error {
+			if !changeDryRun {
+				if err := writeChanges(repo, changes); err != nil {
+					return err
+				}
+			}
+
+			return printChanges(changes, ch)
+		})
+	}
+}
+
+// carryChange makes a change to the repository and carries it through the branch, commit
+// and pull request flow as configured. When a branch is provided, the change is planned
+// against the updated source branch before the branch is checked out, and planned again
+// afterwards, so that repositories which don't need the change are left without a new
+// branch. The plan reports whether the change is needed, and with '--dry-run' the change
+// is expected to only report what it would do.
+func carryChange(repo string, ch chan<- string, plan func() (bool, error), change func() error) error {
+	if err := utils.ValidatePath(repo); err != nil {
+		return err
+	}
+
+	branch := changeBranch != "" && !changeDryRun
+
+	if branch {
+		if err := git.Update(repo, ch); err != nil {
+			return err
+		}
+	}
+
+	if needed, err := plan(); err != nil || !needed {
+		return err
+	}
+
+	if branch {
+		if err := git.Checkout(repo, ch); err != nil {
+			return err
+		}
+
+		// an existing branch may already differ from the source branch
+		if needed, err := plan(); err != nil || !needed {
+			return err
+		}
+	}
+
+	if err := change(); err != nil {
+		return err
+	}
+
+	if changeDryRun {
+		return nil
+	}
+
+	return commitChange(repo, ch)
+}
+
+// commitChange commits and pushes the change and submits a pull request, as configured
+func commitChange(repo string, ch chan<- string) error {
+	if changeMessage == "" {
+		return nil
+	}
+
+	if err := utils.ValidateBranch(repo, ch); err != nil {
+		return err
+	}
+
+	if err := git.Commit(repo, ch); err != nil {
+		return err
+	}
+
+	if !changePR {
+		return nil
+	}
+
+	return pr.New(changeTitle, changeDescription, changeAllReviewers)(repo, ch)
+}
+
+func writeChanges(repo string, changes []fileChange) error {
+	dir, err := utils.RepoPath(repo)
+	if err != nil {
+		return err
+	}
+
+	for _, change := range changes {
+		path := filepath.Join(dir, change.path)
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(path, change.new, change.mode); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func printChanges(changes []fileChange, ch chan<- string) error {
+	for _, change := range changes {
+		diff, err := utils.Diff(change.path, change.old, change.new)
+		if err != nil {
+			return err
+		}
+
+		ch <- diff
+	}
+
+	ch <- fmt.Sprintf("%d file(s) changed\n", len(changes))
+
+	return nil
+}
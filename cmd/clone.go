@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/ryclarke/cisco-batch-tool/call"
+	"github.com/ryclarke/cisco-batch-tool/config"
+	"github.com/ryclarke/cisco-batch-tool/utils"
+)
+
+// defaultCloneParallel limits concurrent clones unless configured otherwise
+const defaultCloneParallel = 8
+
+func addCloneCmd() *cobra.Command {
+	// cloneCmd represents the clone command
+	cloneCmd := &cobra.Command{
+		Use:   "clone <repository> ...",
+		Short: "Clone missing repositories into the workspace",
+		Long: `Clone missing repositories into the workspace
+
+Each provided repository which is not yet present in the workspace is cloned using
+the configured protocol and clone options. Clones run in parallel, limited to 8 at
+a time unless '--max-parallel' is provided, where 0 removes the limit.`,
+		Args:   cobra.MinimumNArgs(1),
+		PreRun: clonePreRun,
+		RunE: func(_ *cobra.Command, args []string) error {
+			repos := selectRepos(args)
+			missing := missingRepos(repos)
+
+			call.Do(args, call.Wrap(reportReady))
+
+			failed := len(missingRepos(repos))
+			fmt.Printf("Cloned %d repositories (%d already present)\n", len(missing)-failed, len(repos)-len(missing))
+
+			if failed > 0 {
+				return fmt.Errorf("failed to clone %d repositories", failed)
+			}
+
+			return nil
+		},
+	}
+
+	addCloneFlags(cloneCmd)
+
+	return cloneCmd
+}
+
+// addCloneFlags adds flags which override the configured clone options
+func addCloneFlags(cmd *cobra.Command) {
+	cmd.Flags().String("protocol", "", "clone protocol (ssh or https)")
+	cmd.Flags().Int("depth", 0, "create shallow clones with the given history depth")
+	cmd.Flags().String("filter", "", "partial clone filter (e.g. blob:none)")
+	cmd.Flags().Bool("single-branch", false, "clone only the history of the default branch")
+	cmd.Flags().String("reference", "", "directory of reference repositories to borrow objects from")
+}
+
+// clonePreRun binds the clone flags of the running command, since viper only keeps the
+// last binding of each key, and limits concurrent clones unless configured otherwise
+func clonePreRun(cmd *cobra.Command, _ []string) {
+	viper.BindPFlag(config.CloneProtocol, cmd.Flags().Lookup("protocol"))
+	viper.BindPFlag(config.CloneDepth, cmd.Flags().Lookup("depth"))
+	viper.BindPFlag(config.CloneFilter, cmd.Flags().Lookup("filter"))
+	viper.BindPFlag(config.CloneSingleBranch, cmd.Flags().Lookup("single-branch"))
+	viper.BindPFlag(config.CloneReference, cmd.Flags().Lookup("reference"))
+
+	// an explicit limit of 0 still means unlimited
+	if !viper.IsSet(config.MaxParallel) {
+		viper.Set(config.MaxParallel, defaultCloneParallel)
+	}
+}
+
+// reportReady is a CallFunc which reports that the repository is present in the workspace
+func reportReady(_ string, ch chan<- string) error {
+	ch <- "Ready\n"
+
+	return nil
+}
+
+// missingRepos returns the repositories which are not present in the workspace
+func missingRepos(repos []string) []string {
+	missing := make([]string, 0, len(repos))
+
+	for _, repo := range repos {
+		// repositories without a valid path are cloned to report the error
+		path, err := utils.ClonePath(repo)
+		if err != nil {
+			missing = append(missing, repo)
+			continue
+		}
+
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			missing = append(missing, repo)
+		}
+	}
+
+	return missing
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/ryclarke/cisco-batch-tool/config"
+)
+
+func addCredentialCmd() *cobra.Command {
+	// credentialCmd represents the credential command (hidden)
+	credentialCmd := &cobra.Command{
+		Use:    "credential <get|store|erase>",
+		Hidden: true,
+		Short:  "Git credential helper providing the auth token for HTTPS remotes",
+		Long: `Git credential helper providing the auth token for HTTPS remotes
+
+This command implements the git credential helper protocol, and is configured
+automatically for repositories cloned with 'git.clone.protocol' set to https.
+Only the 'get' operation is supported, and only for the configured git host.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			// credentials are never stored by this helper
+			if args[0] != "get" {
+				return nil
+			}
+
+			attrs := make(map[string]string)
+
+			scanner := bufio.NewScanner(os.Stdin)
+			for scanner.Scan() {
+				line := scanner.Text()
+				if line == "" {
+					break
+				}
+
+				if key, value, ok := strings.Cut(line, "="); ok {
+					attrs[key] = value
+				}
+			}
+
+			if err := scanner.Err(); err != nil {
+				return err
+			}
+
+			token := viper.GetString(config.AuthToken)
+
+			// let git fall back to other helpers for unrelated hosts
+			if token == "" || attrs["protocol"] != "https" || attrs["host"] != viper.GetString(config.GitHost) {
+				return nil
+			}
+
+			fmt.Printf("username=%s\n", viper.GetString(config.CloneHTTPSUser))
+			fmt.Printf("password=%s\n", token)
+
+			return nil
+		},
+	}
+
+	return credentialCmd
+}
@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ryclarke/cisco-batch-tool/call"
+	"github.com/ryclarke/cisco-batch-tool/utils"
+)
+
+var (
+	depsBuild          bool
+	depsTest           bool
+	depsAllowDowngrade bool
+)
+
+func addDepsCmd() *cobra.Command {
+	// depsCmd represents the deps command
+	depsCmd := &cobra.Command{
+		Use:   "deps",
+		Short: "Inspect and update Go module dependencies across repositories",
+	}
+
+	depsCmd.AddCommand(
+		addDepsBumpCmd(),
+		addDepsGraphCmd(),
+		addDepsAuditCmd(),
+	)
+
+	return depsCmd
+}
+
+func addDepsBumpCmd() *cobra.Command {
+	// depsBumpCmd represents the deps bump command
+	depsBumpCmd := &cobra.Command{
+		Use:   "bump <module>@<version> <repository> ...",
+		Short: "Update a Go module dependency across repositories",
+		Long: `Update a Go module dependency across repositories
+
+Each repository whose go.mod requires the module is updated to the given version
+with 'go get', followed by 'go mod tidy'. With '--build' and '--test', the module
+must also build and pass its tests. Repositories which don't require the module,
+or already require the given version, are skipped. Repositories which require a
+higher version are not downgraded unless '--allow-downgrade' is provided.
+
+The change can be carried through the branch, commit and pull request flow with
+'--branch', '--message' and '--pr'; repositories which fail to update, build or
+test are not committed, and their changes are left in place for inspection.
+With '--dry-run', only the repositories which need the update are listed.`,
+		Args:    cobra.MinimumNArgs(2),
+		PreRunE: validateChangeFlags,
+		Run: func(cmd *cobra.Command, args []string) {
+			module, version, ok := strings.Cut(args[0], "@")
+			if !ok || module == "" || version == "" {
+				fmt.Printf("ERROR: invalid module version %s, expected <module>@<version>\n", args[0])
+				return
+			}
+
+			setChangeConfig(cmd)
+
+			call.Do(args[1:], call.Wrap(bumpModule(module, version)))
+		},
+	}
+
+	depsBumpCmd.Flags().BoolVar(&depsBuild, "build", false, "require 'go build ./...' to succeed after updating")
+	depsBumpCmd.Flags().BoolVar(&depsTest, "test", false, "require 'go test ./...' to succeed after updating")
+	depsBumpCmd.Flags().BoolVar(&depsAllowDowngrade, "allow-downgrade", false, "update repositories which require a higher version")
+
+	addChangeFlags(depsBumpCmd)
+
+	return depsBumpCmd
+}
+
+// bumpModule returns a CallFunc which updates the module to the version in a repository
+// which requires it, then carries the change through the branch, commit and pull request
+// flow as configured.
+func bumpModule(module, version string) call.CallFunc {
+	return func(repo string, ch chan<- string) error {
+		var current string
+
+		return carryChange(repo, ch, func() (bool, error) {
+			var skip bool
+			var err error
+
+			current, skip, err = checkBump(repo, module, version, ch)
+
+			return err == nil && !skip, err
+		}, func() error {
+			if changeDryRun {
+				ch <- fmt.Sprintf("%s %s -> %s\n", module, current, version)
+				return nil
+			}
+
+			steps := [][]string{
+				{"get", module + "@" + version},
+				{"mod", "tidy"},
+			}
+
+			if depsBuild {
+				steps = append(steps, []string{"build", "./..."})
+			}
+
+			if depsTest {
+				steps = append(steps, []string{"test", "./..."})
+			}
+
+			for _, args := range steps {
+				if err := goCommand(repo, ch, args...); err != nil {
+					return err
+				}
+			}
+
+			ch <- fmt.Sprintf("Updated %s %s -> %s\n", module, current, version)
+
+			return nil
+		})
+	}
+}
+
+// checkBump returns the version of the module currently required by the repository, and
+// whether the repository should be skipped because it doesn't need the update. Downgrades
+// are refused unless explicitly allowed.
+func checkBump(repo, module, version string, ch chan<- string) (string, bool, error) {
+	current, err := requiredVersion(repo, module)
+	if err != nil {
+		return "", false, err
+	}
+
+	switch current {
+	case "":
+		ch <- fmt.Sprintf("Skipping: %s is not required\n", module)
+		return current, true, nil
+	case version:
+		ch <- fmt.Sprintf("Skipping: %s is already at %s\n", module, version)
+		return current, true, nil
+	}
+
+	// queries such as 'latest' or a branch name can't be compared before updating
+	from, fromErr := utils.ParseVersion(current)
+	to, toErr := utils.ParseVersion(version)
+
+	if fromErr == nil && toErr == nil && to.Compare(from) < 0 && !depsAllowDowngrade {
+		return current, false, fmt.Errorf("refusing to downgrade %s from %s to %s - use --allow-downgrade", module, current, version)
+	}
+
+	return current, false, nil
+}
+
+// requiredVersion returns the version of the module required by the repository, or an empty
+// string if the repository isn't a Go module or doesn't require it
+func requiredVersion(repo, module string) (string, error) {
+	dir, err := utils.RepoPath(repo)
+	if err != nil {
+		return "", err
+	}
+
+	mod, err := utils.ReadGoMod(dir)
+	if err != nil || mod == nil {
+		return "", err
+	}
+
+	return mod.Requires(module), nil
+}
+
+// goCommand runs the go tool in the repository, ignoring any go.work files around the workspace
+func goCommand(repo string, ch chan<- string, args ...string) error {
+	dir, err := utils.RepoPath(repo)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOWORK=off")
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("go %s: %w: %s", strings.Join(args, " "), err, output)
+	}
+
+	if len(output) > 0 {
+		ch <- string(output)
+	}
+
+	return nil
+}
@@ -0,0 +1,283 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ryclarke/cisco-batch-tool/call"
+	"github.com/ryclarke/cisco-batch-tool/utils"
+)
+
+var (
+	auditBelow string
+	auditJSON  bool
+)
+
+func addDepsAuditCmd() *cobra.Command {
+	// depsAuditCmd represents the deps audit command
+	depsAuditCmd := &cobra.Command{
+		Use:   "audit <module> <repository> ...",
+		Short: "Report the versions of a Go module used across repositories",
+		Long: `Report the versions of a Go module used across repositories
+
+The version of the module used by each repository is read from the go.mod file of
+its local clone, including indirect requirements and replace directives, falling
+back to the highest version listed in go.sum. Repositories are grouped by version.
+
+With '--below', only the repositories using a version lower than the given version
+are listed, and the command fails if there are any.`,
+		Args:         cobra.MinimumNArgs(2),
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, args []string) error {
+			module := args[0]
+
+			var below *utils.Version
+			if auditBelow != "" {
+				version, err := utils.ParseVersion(auditBelow)
+				if err != nil {
+					return err
+				}
+
+				below = &version
+			}
+
+			results := call.Gather(args[1:], func(repo string) (moduleUsage, error) {
+				return auditModule(repo, module)
+			})
+
+			report := buildAuditReport(module, results, below)
+
+			if auditJSON {
+				output, err := json.MarshalIndent(report, "", "  ")
+				if err != nil {
+					return err
+				}
+
+				fmt.Println(string(output))
+			} else {
+				printAuditReport(report)
+			}
+
+			if below != nil && len(report.Outdated) > 0 {
+				return fmt.Errorf("%d repositories use %s below %s", len(report.Outdated), module, auditBelow)
+			}
+
+			return nil
+		},
+	}
+
+	depsAuditCmd.Flags().StringVar(&auditBelow, "below", "", "list repositories using a version lower than this and fail if there are any")
+	depsAuditCmd.Flags().BoolVar(&auditJSON, "json", false, "print the report as JSON")
+
+	return depsAuditCmd
+}
+
+// moduleUsage is the version of a module used by a repository
+type moduleUsage struct {
+	Version string `json:"version"`
+	// Source of the version: require, indirect, replace or go.sum
+	Source string `json:"source"`
+}
+
+func auditModule(repo, module string) (moduleUsage, error) {
+	var usage moduleUsage
+
+	if err := utils.ValidatePath(repo); err != nil {
+		return usage, err
+	}
+
+	dir, err := utils.RepoPath(repo)
+	if err != nil {
+		return usage, err
+	}
+
+	mod, err := utils.ReadGoMod(dir)
+	if err != nil || mod == nil {
+		return usage, err
+	}
+
+	for _, req := range mod.Require {
+		if req.Path == module {
+			usage.Version, usage.Source = req.Version, "require"
+			if req.Indirect {
+				usage.Source = "indirect"
+			}
+		}
+	}
+
+	// a replace directive applies to all versions, or only to the version it names
+	for _, rep := range mod.Replace {
+		if rep.Old.Path != module || (rep.Old.Version != "" && rep.Old.Version != usage.Version) {
+			continue
+		}
+
+		usage.Source = "replace"
+
+		if rep.New.Version == "" {
+			usage.Version = rep.New.Path // local directory
+		} else if rep.New.Path == module {
+			usage.Version = rep.New.Version
+		} else {
+			usage.Version = rep.New.Path + "@" + rep.New.Version
+		}
+	}
+
+	if usage.Version != "" {
+		return usage, nil
+	}
+
+	version, err := goSumVersion(repo, module)
+	if err != nil || version == "" {
+		return usage, err
+	}
+
+	return moduleUsage{Version: version, Source: "go.sum"}, nil
+}
+
+// goSumVersion returns the highest version of the module with a checksum in go.sum
+func goSumVersion(repo, module string) (string, error) {
+	dir, err := utils.RepoPath(repo)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.Open(filepath.Join(dir, "go.sum"))
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	defer file.Close()
+
+	var highest string
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+
+		// lines only for the go.mod of a module don't mean its code is used
+		if len(fields) != 3 || fields[0] != module || strings.HasSuffix(fields[1], "/go.mod") {
+			continue
+		}
+
+		if highest == "" || compareVersions(fields[1], highest) > 0 {
+			highest = fields[1]
+		}
+	}
+
+	return highest, scanner.Err()
+}
+
+// compareVersions compares semantic versions, falling back to lexical order
+func compareVersions(a, b string) int {
+	va, errA := utils.ParseVersion(a)
+	vb, errB := utils.ParseVersion(b)
+
+	if errA == nil && errB == nil {
+		return va.Compare(vb)
+	}
+
+	return strings.Compare(a, b)
+}
+
+// auditReport groups repositories by the version of the module they use
+type auditReport struct {
+	Module   string         `json:"module"`
+	Versions []auditVersion `json:"versions"`
+	Unused   []string       `json:"unused"`
+	Outdated []string       `json:"outdated,omitempty"`
+	Errors   []repoError    `json:"errors,omitempty"`
+}
+
+type auditVersion struct {
+	Version      string   `json:"version"`
+	Repositories []string `json:"repositories"`
+	Sources      []string `json:"sources"`
+}
+
+func buildAuditReport(module string, results []call.Result[moduleUsage], below *utils.Version) *auditReport {
+	report := &auditReport{Module: module, Versions: []auditVersion{}, Unused: []string{}}
+	versions := make(map[string]*auditVersion)
+
+	for _, result := range results {
+		switch {
+		case result.Err != nil:
+			report.Errors = append(report.Errors, repoError{Repository: result.Repo, Error: result.Err.Error()})
+			continue
+		case result.Value.Version == "":
+			report.Unused = append(report.Unused, result.Repo)
+			continue
+		}
+
+		entry, ok := versions[result.Value.Version]
+		if !ok {
+			entry = &auditVersion{Version: result.Value.Version}
+			versions[result.Value.Version] = entry
+		}
+
+		entry.Repositories = append(entry.Repositories, result.Repo)
+		entry.Sources = append(entry.Sources, result.Value.Source)
+
+		// versions which aren't semantic (e.g. local replacements) can't be compared
+		if version, err := utils.ParseVersion(result.Value.Version); below != nil && err == nil && version.Compare(*below) < 0 {
+			report.Outdated = append(report.Outdated, result.Repo)
+		}
+	}
+
+	for _, entry := range versions {
+		report.Versions = append(report.Versions, *entry)
+	}
+
+	// highest version first
+	sort.Slice(report.Versions, func(i, j int) bool {
+		return compareVersions(report.Versions[i].Version, report.Versions[j].Version) > 0
+	})
+
+	return report
+}
+
+func printAuditReport(report *auditReport) {
+	if auditBelow != "" {
+		fmt.Printf("Repositories using %s below %s:\n", report.Module, auditBelow)
+
+		for _, repo := range report.Outdated {
+			fmt.Printf("  %s\n", repo)
+		}
+
+		fmt.Println()
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tCOUNT\tREPOSITORIES")
+
+	for _, entry := range report.Versions {
+		repos := make([]string, len(entry.Repositories))
+		for i, repo := range entry.Repositories {
+			repos[i] = repo
+			if entry.Sources[i] != "require" {
+				repos[i] += " (" + entry.Sources[i] + ")"
+			}
+		}
+
+		fmt.Fprintf(w, "%s\t%d\t%s\n", entry.Version, len(repos), strings.Join(repos, ", "))
+	}
+
+	if len(report.Unused) > 0 {
+		fmt.Fprintf(w, "%s\t%d\t%s\n", "unused", len(report.Unused), strings.Join(report.Unused, ", "))
+	}
+
+	w.Flush()
+
+	for _, err := range report.Errors {
+		fmt.Printf("ERROR: %s: %s\n", err.Repository, err.Error)
+	}
+}
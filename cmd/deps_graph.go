@@ -0,0 +1,394 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ryclarke/cisco-batch-tool/call"
+	"github.com/ryclarke/cisco-batch-tool/utils"
+)
+
+// Supported dependency ecosystems
+const (
+	ecosystemGo  = "go"
+	ecosystemNPM = "npm"
+	ecosystemPip = "pip"
+)
+
+var (
+	graphEcosystems []string
+	graphFormat     string
+)
+
+func addDepsGraphCmd() *cobra.Command {
+	// depsGraphCmd represents the deps graph command
+	depsGraphCmd := &cobra.Command{
+		Use:   "graph <repository> ...",
+		Short: "Graph the dependencies between repositories",
+		Long: `Graph the dependencies between repositories
+
+The dependency manifests of each local clone are parsed to find which of the
+selected repositories depend on each other, and at which versions. Go modules are
+matched by their module path (go.mod), npm packages by their package name
+(package.json), and pip requirements by the repository name (requirements.txt).
+
+The graph is rendered as DOT (default), Mermaid or JSON. A dependency pinned to a
+version older than the latest version tag of the repository providing it is
+highlighted as outdated.`,
+		Args: cobra.MinimumNArgs(1),
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			for _, ecosystem := range graphEcosystems {
+				switch ecosystem {
+				case ecosystemGo, ecosystemNPM, ecosystemPip:
+				default:
+					return fmt.Errorf("invalid ecosystem %q - must be go, npm or pip", ecosystem)
+				}
+			}
+
+			switch graphFormat {
+			case "dot", "mermaid", "json":
+				return nil
+			default:
+				return fmt.Errorf("invalid format %q - must be dot, mermaid or json", graphFormat)
+			}
+		},
+		Run: func(_ *cobra.Command, args []string) {
+			results := call.Gather(args, gatherDeps)
+
+			graph := buildDepGraph(results)
+
+			switch graphFormat {
+			case "mermaid":
+				printMermaid(graph)
+			case "json":
+				output, err := json.MarshalIndent(graph, "", "  ")
+				if err != nil {
+					fmt.Println("ERROR:", err)
+					return
+				}
+
+				fmt.Println(string(output))
+			default:
+				printDOT(graph)
+			}
+
+			// keep stdout limited to the graph so it can be rendered directly
+			for _, result := range results {
+				if result.Err != nil {
+					fmt.Fprintf(os.Stderr, "ERROR: %s: %v\n", result.Repo, result.Err)
+				}
+			}
+		},
+	}
+
+	depsGraphCmd.Flags().StringSliceVarP(&graphEcosystems, "ecosystem", "e", []string{ecosystemGo}, "dependency ecosystems to parse (go, npm, pip)")
+	depsGraphCmd.Flags().StringVarP(&graphFormat, "format", "f", "dot", "output format (dot, mermaid or json)")
+
+	return depsGraphCmd
+}
+
+// repoDeps describes the packages provided and required by a repository
+type repoDeps struct {
+	// Provides maps each ecosystem to the name of the package provided by the repository
+	Provides map[string]string
+	Requires []dependency
+	Latest   *utils.Version
+}
+
+// dependency is a single package requirement
+type dependency struct {
+	Ecosystem string
+	Name      string
+	Version   string
+}
+
+func gatherDeps(repo string) (repoDeps, error) {
+	deps := repoDeps{Provides: make(map[string]string)}
+
+	if err := utils.ValidatePath(repo); err != nil {
+		return deps, err
+	}
+
+	for _, ecosystem := range graphEcosystems {
+		var err error
+
+		switch ecosystem {
+		case ecosystemGo:
+			err = parseGoDeps(repo, &deps)
+		case ecosystemNPM:
+			err = parseNPMDeps(repo, &deps)
+		case ecosystemPip:
+			err = parsePipDeps(repo, &deps)
+		}
+
+		if err != nil {
+			return deps, err
+		}
+	}
+
+	latest, err := utils.LatestVersion(repo)
+	if err != nil {
+		return deps, err
+	}
+
+	deps.Latest = latest
+
+	return deps, nil
+}
+
+func parseGoDeps(repo string, deps *repoDeps) error {
+	dir, err := utils.RepoPath(repo)
+	if err != nil {
+		return err
+	}
+
+	mod, err := utils.ReadGoMod(dir)
+	if err != nil || mod == nil {
+		return err
+	}
+
+	deps.Provides[ecosystemGo] = mod.Module.Path
+
+	for _, req := range mod.Require {
+		if !req.Indirect {
+			deps.Requires = append(deps.Requires, dependency{Ecosystem: ecosystemGo, Name: req.Path, Version: req.Version})
+		}
+	}
+
+	return nil
+}
+
+func parseNPMDeps(repo string, deps *repoDeps) error {
+	dir, err := utils.RepoPath(repo)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var pkg struct {
+		Name            string            `json:"name"`
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return fmt.Errorf("invalid package.json: %w", err)
+	}
+
+	if pkg.Name != "" {
+		deps.Provides[ecosystemNPM] = pkg.Name
+	}
+
+	for _, group := range []map[string]string{pkg.Dependencies, pkg.DevDependencies} {
+		for name, version := range group {
+			deps.Requires = append(deps.Requires, dependency{Ecosystem: ecosystemNPM, Name: name, Version: version})
+		}
+	}
+
+	return nil
+}
+
+func parsePipDeps(repo string, deps *repoDeps) error {
+	dir, err := utils.RepoPath(repo)
+	if err != nil {
+		return err
+	}
+
+	// requirements don't name the package they belong to, so the repository name is used
+	deps.Provides[ecosystemPip] = normalizePipName(repo)
+
+	file, err := os.Open(filepath.Join(dir, "requirements.txt"))
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		line = strings.TrimSpace(line)
+
+		// skip options such as -r, -e and --index-url
+		if line == "" || strings.HasPrefix(line, "-") {
+			continue
+		}
+
+		line, _, _ = strings.Cut(line, ";")
+
+		i := strings.IndexAny(line, "=<>!~[ ")
+		if i < 0 {
+			i = len(line)
+		}
+
+		version := strings.TrimSpace(line[i:])
+		if strings.HasPrefix(version, "==") {
+			version = strings.TrimSpace(version[2:])
+		}
+
+		deps.Requires = append(deps.Requires, dependency{Ecosystem: ecosystemPip, Name: normalizePipName(line[:i]), Version: version})
+	}
+
+	return scanner.Err()
+}
+
+func normalizePipName(name string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), "_", "-")
+}
+
+// depGraph is the graph of dependencies between repositories
+type depGraph struct {
+	Nodes []depNode `json:"nodes"`
+	Edges []depEdge `json:"edges"`
+}
+
+type depNode struct {
+	Repository string            `json:"repository"`
+	Packages   map[string]string `json:"packages,omitempty"`
+	Latest     string            `json:"latest,omitempty"`
+}
+
+// depEdge is a dependency of one repository on a package provided by another
+type depEdge struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Ecosystem string `json:"ecosystem"`
+	Package   string `json:"package"`
+	Version   string `json:"version"`
+	Outdated  bool   `json:"outdated"`
+}
+
+func buildDepGraph(results []call.Result[repoDeps]) depGraph {
+	graph := depGraph{Nodes: []depNode{}, Edges: []depEdge{}}
+	providers := make(map[dependency]string)
+	latest := make(map[string]*utils.Version)
+
+	for _, result := range results {
+		if result.Err != nil {
+			continue
+		}
+
+		node := depNode{Repository: result.Repo, Packages: result.Value.Provides}
+		if result.Value.Latest != nil {
+			node.Latest = result.Value.Latest.String()
+			latest[result.Repo] = result.Value.Latest
+		}
+
+		graph.Nodes = append(graph.Nodes, node)
+
+		for ecosystem, name := range result.Value.Provides {
+			providers[dependency{Ecosystem: ecosystem, Name: name}] = result.Repo
+		}
+	}
+
+	for _, result := range results {
+		if result.Err != nil {
+			continue
+		}
+
+		for _, dep := range result.Value.Requires {
+			provider, ok := providers[dependency{Ecosystem: dep.Ecosystem, Name: dep.Name}]
+			if !ok || provider == result.Repo {
+				continue
+			}
+
+			edge := depEdge{
+				From:      result.Repo,
+				To:        provider,
+				Ecosystem: dep.Ecosystem,
+				Package:   dep.Name,
+				Version:   dep.Version,
+			}
+
+			// npm version ranges are compared by their lower bound
+			if version, err := utils.ParseVersion(strings.TrimLeft(dep.Version, "^~=")); err == nil && latest[provider] != nil {
+				edge.Outdated = version.Compare(*latest[provider]) < 0
+			}
+
+			graph.Edges = append(graph.Edges, edge)
+		}
+	}
+
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		a, b := graph.Edges[i], graph.Edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+
+		return a.To < b.To
+	})
+
+	return graph
+}
+
+func printDOT(graph depGraph) {
+	fmt.Println("digraph dependencies {")
+	fmt.Println("  rankdir=LR;")
+
+	for _, node := range graph.Nodes {
+		label := node.Repository
+		if node.Latest != "" {
+			label += "\\n" + node.Latest
+		}
+
+		fmt.Printf("  %q [label=\"%s\"];\n", node.Repository, label)
+	}
+
+	for _, edge := range graph.Edges {
+		attrs := fmt.Sprintf("label=%q", edge.Version)
+		if edge.Outdated {
+			attrs += ", color=red, fontcolor=red"
+		}
+
+		fmt.Printf("  %q -> %q [%s];\n", edge.From, edge.To, attrs)
+	}
+
+	fmt.Println("}")
+}
+
+func printMermaid(graph depGraph) {
+	fmt.Println("graph LR")
+
+	// mermaid node IDs can't contain most punctuation, so repositories are numbered
+	ids := make(map[string]string, len(graph.Nodes))
+
+	for i, node := range graph.Nodes {
+		ids[node.Repository] = fmt.Sprintf("n%d", i)
+
+		label := node.Repository
+		if node.Latest != "" {
+			label += "<br/>" + node.Latest
+		}
+
+		fmt.Printf("  %s[\"%s\"]\n", ids[node.Repository], label)
+	}
+
+	var outdated []string
+
+	for i, edge := range graph.Edges {
+		fmt.Printf("  %s -->|\"%s\"| %s\n", ids[edge.From], edge.Version, ids[edge.To])
+
+		if edge.Outdated {
+			outdated = append(outdated, fmt.Sprint(i))
+		}
+	}
+
+	if len(outdated) > 0 {
+		fmt.Printf("  linkStyle %s stroke:red,color:red\n", strings.Join(outdated, ","))
+	}
+}
@@ -0,0 +1,263 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"text/template"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/ryclarke/cisco-batch-tool/call"
+	"github.com/ryclarke/cisco-batch-tool/catalog"
+	"github.com/ryclarke/cisco-batch-tool/config"
+	"github.com/ryclarke/cisco-batch-tool/utils"
+)
+
+// templateSuffix marks source files which are rendered as Go templates
+const templateSuffix = ".tmpl"
+
+func addFilesCmd() *cobra.Command {
+	// filesCmd represents the files command
+	filesCmd := &cobra.Command{
+		Use:   "files",
+		Short: "Manage files shared across repositories",
+	}
+
+	filesCmd.AddCommand(
+		addFilesSyncCmd(),
+		addFilesDiffCmd(),
+	)
+
+	return filesCmd
+}
+
+func addFilesSyncCmd() *cobra.Command {
+	// filesSyncCmd represents the files sync command
+	filesSyncCmd := &cobra.Command{
+		Use:   "sync <source-dir> <repository> ...",
+		Short: "Synchronize canonical files into repositories",
+		Long: `Synchronize canonical files into repositories
+
+Each file in the source directory is copied to the same relative path in each
+repository. Files ending in '` + templateSuffix + `' are rendered as Go templates
+instead, with the suffix removed from the destination path, using the catalog
+fields of the repository:
+
+  {{.Name}} {{.Description}} {{.Project}} {{.Public}} {{.Labels}} {{.CloneURL}}
+
+A diff is shown for each repository which has drifted from the source files; with
+'--dry-run', the files are not modified. The change can be carried through the
+branch, commit and pull request flow with '--branch', '--message' and '--pr'.
+Finally, the drifted and in-sync repositories are summarized.`,
+		Args:    cobra.MinimumNArgs(2),
+		PreRunE: validateChangeFlags,
+		Run: func(cmd *cobra.Command, args []string) {
+			sources, err := loadSyncSources(args[0])
+			if err != nil {
+				fmt.Println("ERROR:", err)
+				return
+			}
+
+			setChangeConfig(cmd)
+
+			drift := &syncDrift{repos: make(map[string]bool)}
+
+			call.Do(args[1:], call.Wrap(applyChange(func(repo string) ([]fileChange, error) {
+				changes, err := planSync(repo, sources)
+				if err == nil {
+					drift.add(repo, len(changes) > 0)
+				}
+
+				return changes, err
+			})))
+
+			drift.print(selectRepos(args[1:]))
+		},
+	}
+
+	addChangeFlags(filesSyncCmd)
+
+	return filesSyncCmd
+}
+
+// syncDrift records whether each repository has drifted from the source files
+type syncDrift struct {
+	repos map[string]bool
+	mu    sync.Mutex
+}
+
+// add records the drift of a repository, which counts as drifted if any plan found changes
+func (d *syncDrift) add(repo string, drifted bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.repos[repo] = d.repos[repo] || drifted
+}
+
+// print summarizes the drift of the repositories, including any which couldn't be checked
+func (d *syncDrift) print(repos []string) {
+	var drifted, synced, unknown []string
+
+	for _, repo := range repos {
+		switch isDrifted, ok := d.repos[repo]; {
+		case !ok:
+			unknown = append(unknown, repo)
+		case isDrifted:
+			drifted = append(drifted, repo)
+		default:
+			synced = append(synced, repo)
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+
+	list := func(repos []string) string {
+		if len(repos) == 0 {
+			return "-"
+		}
+
+		return strings.Join(repos, ", ")
+	}
+
+	fmt.Fprintf(w, "Drifted (%d):\t%s\n", len(drifted), list(drifted))
+	fmt.Fprintf(w, "In sync (%d):\t%s\n", len(synced), list(synced))
+
+	if len(unknown) > 0 {
+		fmt.Fprintf(w, "Not checked (%d):\t%s\n", len(unknown), list(unknown))
+	}
+
+	w.Flush()
+}
+
+// syncSource is a canonical file to synchronize into each repository
+type syncSource struct {
+	path    string
+	mode    os.FileMode
+	content []byte
+	tmpl    *template.Template
+}
+
+// loadSyncSources reads and parses every file in the source directory
+func loadSyncSources(dir string) ([]syncSource, error) {
+	var sources []syncSource
+
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			if entry.Name() == ".git" {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		source := syncSource{path: rel, mode: info.Mode().Perm(), content: content}
+
+		if strings.HasSuffix(rel, templateSuffix) {
+			source.path = strings.TrimSuffix(rel, templateSuffix)
+
+			source.tmpl, err = template.New(rel).Option("missingkey=error").Parse(string(content))
+			if err != nil {
+				return err
+			}
+		}
+
+		sources = append(sources, source)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no files found in %s", dir)
+	}
+
+	return sources, nil
+}
+
+// planSync returns the changes needed for the repository to match the source files
+func planSync(repo string, sources []syncSource) ([]fileChange, error) {
+	dir, err := utils.RepoPath(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	data, ok := catalog.Catalog[repo]
+	if !ok {
+		data = catalog.Repository{Name: repo}
+	}
+
+	if data.Project == "" {
+		data.Project = viper.GetString(config.GitProject)
+	}
+
+	if data.CloneURL == "" {
+		data.CloneURL = catalog.CloneURL(repo)
+	}
+
+	var changes []fileChange
+
+	for _, source := range sources {
+		content := source.content
+
+		if source.tmpl != nil {
+			var buf bytes.Buffer
+			if err := source.tmpl.Execute(&buf, data); err != nil {
+				return nil, err
+			}
+
+			content = buf.Bytes()
+		}
+
+		path := filepath.Join(dir, source.path)
+
+		old, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			changes = append(changes, fileChange{path: source.path, mode: source.mode, new: content})
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		if bytes.Equal(old, content) {
+			continue
+		}
+
+		// existing files keep their mode
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		changes = append(changes, fileChange{path: source.path, mode: info.Mode().Perm(), old: old, new: content})
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ryclarke/cisco-batch-tool/call"
+	"github.com/ryclarke/cisco-batch-tool/utils"
+)
+
+var (
+	filesDiffReference string
+	filesDiffJSON      bool
+)
+
+func addFilesDiffCmd() *cobra.Command {
+	// filesDiffCmd represents the files diff command
+	filesDiffCmd := &cobra.Command{
+		Use:   "diff <path> <repository> ...",
+		Short: "Compare a file across repositories",
+		Long: `Compare a file across repositories
+
+The file at the given path is read from each repository, and the repositories are
+grouped by the content of the file. Each variant is compared against the most
+common variant, or against the '--reference' file if provided, and the diff is
+shown along with the repositories using it. Repositories without the file are
+listed separately.`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(_ *cobra.Command, args []string) error {
+			path := filepath.Clean(args[0])
+			if !filepath.IsLocal(path) {
+				return fmt.Errorf("invalid path %q - must be within the repository", args[0])
+			}
+
+			var reference *fileVariant
+			if filesDiffReference != "" {
+				content, err := os.ReadFile(filesDiffReference)
+				if err != nil {
+					return err
+				}
+
+				reference = &fileVariant{Hash: contentHash(content), content: content}
+			}
+
+			results := call.Gather(args[1:], func(repo string) ([]byte, error) {
+				if err := utils.ValidatePath(repo); err != nil {
+					return nil, err
+				}
+
+				dir, err := utils.RepoPath(repo)
+				if err != nil {
+					return nil, err
+				}
+
+				content, err := os.ReadFile(filepath.Join(dir, path))
+				if os.IsNotExist(err) {
+					return nil, nil
+				}
+
+				return content, err
+			})
+
+			report, err := compareFiles(path, results, reference)
+			if err != nil {
+				return err
+			}
+
+			if filesDiffJSON {
+				output, err := json.MarshalIndent(report, "", "  ")
+				if err != nil {
+					return err
+				}
+
+				fmt.Println(string(output))
+
+				return nil
+			}
+
+			printFileReport(report)
+
+			return nil
+		},
+	}
+
+	filesDiffCmd.Flags().StringVar(&filesDiffReference, "reference", "", "compare against this file instead of the most common variant")
+	filesDiffCmd.Flags().BoolVar(&filesDiffJSON, "json", false, "print the report as JSON")
+
+	return filesDiffCmd
+}
+
+// fileReport groups repositories by the content of a file
+type fileReport struct {
+	Path      string        `json:"path"`
+	Reference string        `json:"reference"`
+	Variants  []fileVariant `json:"variants"`
+	Missing   []string      `json:"missing"`
+	Errors    []repoError   `json:"errors,omitempty"`
+}
+
+// fileVariant is a distinct content of the file and the repositories using it
+type fileVariant struct {
+	Hash         string   `json:"hash"`
+	Repositories []string `json:"repositories"`
+	// Diff against the reference content, empty for the reference itself
+	Diff string `json:"diff,omitempty"`
+
+	content []byte
+}
+
+type repoError struct {
+	Repository string `json:"repository"`
+	Error      string `json:"error"`
+}
+
+func compareFiles(path string, results []call.Result[[]byte], reference *fileVariant) (*fileReport, error) {
+	report := &fileReport{Path: path, Missing: []string{}}
+	variants := make(map[string]*fileVariant)
+
+	for _, result := range results {
+		switch {
+		case result.Err != nil:
+			report.Errors = append(report.Errors, repoError{Repository: result.Repo, Error: result.Err.Error()})
+			continue
+		case result.Value == nil:
+			report.Missing = append(report.Missing, result.Repo)
+			continue
+		}
+
+		hash := contentHash(result.Value)
+		if _, ok := variants[hash]; !ok {
+			variants[hash] = &fileVariant{Hash: hash, content: result.Value}
+		}
+
+		variants[hash].Repositories = append(variants[hash].Repositories, result.Repo)
+	}
+
+	for _, variant := range variants {
+		report.Variants = append(report.Variants, *variant)
+	}
+
+	// most common variant first, then by hash for a stable order
+	sort.Slice(report.Variants, func(i, j int) bool {
+		a, b := report.Variants[i], report.Variants[j]
+		if len(a.Repositories) != len(b.Repositories) {
+			return len(a.Repositories) > len(b.Repositories)
+		}
+
+		return a.Hash < b.Hash
+	})
+
+	if reference == nil {
+		if len(report.Variants) == 0 {
+			return report, nil
+		}
+
+		reference = &report.Variants[0]
+	}
+
+	report.Reference = reference.Hash
+
+	for i := range report.Variants {
+		if report.Variants[i].Hash == reference.Hash {
+			continue
+		}
+
+		diff, err := utils.Diff(path, reference.content, report.Variants[i].content)
+		if err != nil {
+			return nil, err
+		}
+
+		report.Variants[i].Diff = diff
+	}
+
+	return report, nil
+}
+
+func printFileReport(report *fileReport) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "VARIANT\tCOUNT\tREPOSITORIES")
+
+	for _, variant := range report.Variants {
+		name := variant.Hash
+		if variant.Hash == report.Reference {
+			name += " (reference)"
+		}
+
+		fmt.Fprintf(w, "%s\t%d\t%s\n", name, len(variant.Repositories), strings.Join(variant.Repositories, ", "))
+	}
+
+	if len(report.Missing) > 0 {
+		fmt.Fprintf(w, "%s\t%d\t%s\n", "missing", len(report.Missing), strings.Join(report.Missing, ", "))
+	}
+
+	w.Flush()
+
+	for _, err := range report.Errors {
+		fmt.Printf("ERROR: %s: %s\n", err.Repository, err.Error)
+	}
+
+	for _, variant := range report.Variants {
+		if variant.Diff == "" {
+			continue
+		}
+
+		fmt.Printf("\n------ %s (%s) ------\n%s", variant.Hash, strings.Join(variant.Repositories, ", "), variant.Diff)
+	}
+}
+
+// contentHash returns a short hash identifying the content
+func contentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+
+	return hex.EncodeToString(sum[:])[:12]
+}
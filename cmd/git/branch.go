@@ -1,6 +1,8 @@
 package git
 
 import (
+	"fmt"
+	"os"
 	"os/exec"
 
 	"github.com/spf13/cobra"
@@ -17,15 +19,41 @@ func addBranchCmd() *cobra.Command {
 		Use:     "branch <repository> ...",
 		Aliases: []string{"checkout"},
 		Short:   "Checkout a new branch across repositories",
-		Args:    cobra.MinimumNArgs(1),
+		Long: `Checkout a new branch across repositories
+
+With '--worktree', the branch is checked out in a separate worktree next to the
+primary clone (in the form '<repository>@<branch>') instead, leaving the primary
+clone untouched. Other commands operate on the same worktree when given the same
+'--worktree' flag. The branch name defaults to the worktree branch.`,
+		Args: cobra.MinimumNArgs(1),
 		PreRunE: func(_ *cobra.Command, _ []string) error {
+			if worktree := viper.GetString(config.Worktree); worktree != "" {
+				if branch := viper.GetString(config.Branch); branch == "" {
+					viper.Set(config.Branch, worktree)
+				} else if branch != worktree {
+					return fmt.Errorf("branch %s does not match worktree %s", branch, worktree)
+				}
+			}
+
 			return utils.ValidateRequiredConfig(config.Branch)
 		},
 		Run: func(cmd *cobra.Command, args []string) {
+			if viper.GetString(config.Worktree) != "" {
+				call.Do(args, call.Wrap(gitWorktree))
+				return
+			}
+
+			if stash, _ := cmd.Flags().GetBool("autostash"); stash {
+				call.Do(args, call.Wrap(autostash(false, gitUpdate, gitCheckout)))
+				return
+			}
+
 			call.Do(args, call.Wrap(gitUpdate, gitCheckout))
 		},
 	}
 
+	branchCmd.Flags().Bool("autostash", false, "stash local changes before switching branches and restore them afterwards")
+
 	branchCmd.Flags().StringP("branch", "b", "", "branch name (required)")
 	viper.BindPFlag(config.Branch, branchCmd.Flags().Lookup("branch"))
 
@@ -33,15 +61,20 @@ func addBranchCmd() *cobra.Command {
 }
 
 func gitCheckout(name string, ch chan<- string) error {
+	dir, err := utils.RepoPath(name)
+	if err != nil {
+		return err
+	}
+
 	branch := viper.GetString(config.Branch)
 
 	cmd := exec.Command("git", "checkout", branch)
-	cmd.Dir = utils.RepoPath(name)
+	cmd.Dir = dir
 
 	output, err := cmd.Output()
 	if err != nil {
 		cmd = exec.Command("git", "checkout", "-b", branch)
-		cmd.Dir = utils.RepoPath(name)
+		cmd.Dir = dir
 
 		output, err = cmd.Output()
 		if err != nil {
@@ -51,7 +84,7 @@ func gitCheckout(name string, ch chan<- string) error {
 		ch <- string(output)
 
 		cmd = exec.Command("git", "push", "-u", "origin", branch)
-		cmd.Dir = utils.RepoPath(name)
+		cmd.Dir = dir
 
 		output, err = cmd.Output()
 		if err != nil {
@@ -63,3 +96,79 @@ func gitCheckout(name string, ch chan<- string) error {
 
 	return nil
 }
+
+// gitWorktree creates a worktree for the branch from the primary clone, creating
+// and pushing a new branch from the source branch if it doesn't exist yet.
+func gitWorktree(name string, ch chan<- string) error {
+	branch := viper.GetString(config.Branch)
+
+	path, err := utils.RepoPath(name)
+	if err != nil {
+		return err
+	}
+
+	clone, err := utils.ClonePath(name)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		ch <- fmt.Sprintf("Using existing worktree %s\n", path)
+		return nil
+	}
+
+	cmd := exec.Command("git", "fetch", "origin")
+	cmd.Dir = clone
+
+	if _, err := cmd.Output(); err != nil {
+		return err
+	}
+
+	// an existing local or remote branch is checked out as-is
+	if branchExists(clone, branch) {
+		cmd = exec.Command("git", "worktree", "add", path, branch)
+		cmd.Dir = clone
+
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("%w: %s", err, output)
+		}
+
+		ch <- string(output)
+
+		return nil
+	}
+
+	cmd = exec.Command("git", "worktree", "add", "-b", branch, path, "origin/"+viper.GetString(config.SourceBranch))
+	cmd.Dir = clone
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, output)
+	}
+
+	ch <- string(output)
+
+	cmd = exec.Command("git", "push", "-u", "origin", branch)
+	cmd.Dir = path
+
+	output, err = cmd.Output()
+	if err != nil {
+		return err
+	}
+
+	ch <- string(output)
+
+	return nil
+}
+
+// branchExists returns true if the branch exists locally or on the origin remote
+func branchExists(dir, branch string) bool {
+	for _, ref := range []string{"refs/heads/" + branch, "refs/remotes/origin/" + branch} {
+		if _, err := utils.GitOutput(dir, "rev-parse", "--verify", "--quiet", ref); err == nil {
+			return true
+		}
+	}
+
+	return false
+}
@@ -39,10 +39,15 @@ func addCommitCmd() *cobra.Command {
 }
 
 func gitCommit(name string, ch chan<- string) error {
+	dir, err := utils.RepoPath(name)
+	if err != nil {
+		return err
+	}
+
 	cmd := exec.Command("git", "add", ".")
-	cmd.Dir = utils.RepoPath(name)
+	cmd.Dir = dir
 
-	_, err := cmd.Output()
+	_, err = cmd.Output()
 	if err != nil {
 		return err
 	}
@@ -62,7 +67,7 @@ func gitCommit(name string, ch chan<- string) error {
 	}
 
 	cmd = exec.Command("git", args...)
-	cmd.Dir = utils.RepoPath(name)
+	cmd.Dir = dir
 
 	output, err := cmd.Output()
 	if err != nil {
@@ -77,7 +82,7 @@ func gitCommit(name string, ch chan<- string) error {
 	}
 
 	cmd = exec.Command("git", args...)
-	cmd.Dir = utils.RepoPath(name)
+	cmd.Dir = dir
 
 	output, err = cmd.Output()
 	if err != nil {
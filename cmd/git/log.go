@@ -0,0 +1,230 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ryclarke/cisco-batch-tool/call"
+	"github.com/ryclarke/cisco-batch-tool/utils"
+)
+
+var (
+	logSince     string
+	logAuthor    string
+	logGrep      string
+	logFrom      string
+	logTo        string
+	logSinceTag  bool
+	logNoMerges  bool
+	logLimit     int
+	logChangelog bool
+)
+
+func addLogCmd() *cobra.Command {
+	// logCmd represents the git log command
+	logCmd := &cobra.Command{
+		Use:   "log <repository> ...",
+		Short: "Combined commit log across repositories",
+		Long: `Combined commit log across repositories
+
+Commits from all repositories are merged into a single chronological view. Commits
+may be filtered by date, author and message, and limited to a range of refs with
+'--from' and '--to', or to the commits since the latest version tag of each
+repository with '--since-tag'.
+
+With '--changelog', the commits are grouped by repository instead and rendered as
+Markdown for release notes.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			results := call.Gather(args, gatherLog)
+
+			if logChangelog {
+				printChangelog(results)
+			} else {
+				printLog(results)
+			}
+		},
+	}
+
+	logCmd.Flags().StringVar(&logSince, "since", "", "only show commits more recent than a date (e.g. 2.weeks or 2024-01-31)")
+	logCmd.Flags().StringVar(&logAuthor, "author", "", "only show commits by a matching author")
+	logCmd.Flags().StringVar(&logGrep, "grep", "", "only show commits with a matching message")
+	logCmd.Flags().StringVar(&logFrom, "from", "", "only show commits after this ref")
+	logCmd.Flags().StringVar(&logTo, "to", "HEAD", "only show commits up to this ref")
+	logCmd.Flags().BoolVar(&logSinceTag, "since-tag", false, "only show commits since the latest version tag")
+	logCmd.Flags().BoolVar(&logNoMerges, "no-merges", false, "exclude merge commits")
+	logCmd.Flags().IntVarP(&logLimit, "limit", "n", 0, "maximum number of commits to show (default: unlimited)")
+	logCmd.Flags().BoolVar(&logChangelog, "changelog", false, "render commits grouped by repository as Markdown")
+
+	return logCmd
+}
+
+// commitInfo describes a single commit of a repository
+type commitInfo struct {
+	Hash    string
+	Author  string
+	Date    time.Time
+	Subject string
+}
+
+// repoLog is the list of commits in the selected range of a repository
+type repoLog struct {
+	Range   string
+	Commits []commitInfo
+}
+
+func gatherLog(repo string) (repoLog, error) {
+	var result repoLog
+
+	if err := utils.ValidatePath(repo); err != nil {
+		return result, err
+	}
+
+	dir, err := utils.RepoPath(repo)
+	if err != nil {
+		return result, err
+	}
+
+	from := logFrom
+	if logSinceTag {
+		latest, err := utils.LatestVersion(repo)
+		if err != nil {
+			return result, err
+		}
+
+		// without any tags, the full history is included
+		if latest != nil {
+			from = latest.String()
+		}
+	}
+
+	result.Range = logTo
+	if from != "" {
+		result.Range = from + ".." + logTo
+	}
+
+	// fields are separated by the unit separator, which can't appear in a subject
+	args := []string{"log", "--format=%H%x1f%an%x1f%aI%x1f%s"}
+
+	if logSince != "" {
+		args = append(args, "--since="+logSince)
+	}
+
+	if logAuthor != "" {
+		args = append(args, "--author="+logAuthor)
+	}
+
+	if logGrep != "" {
+		args = append(args, "--grep="+logGrep)
+	}
+
+	if logNoMerges {
+		args = append(args, "--no-merges")
+	}
+
+	output, err := utils.GitOutput(dir, append(args, result.Range, "--")...)
+	if err != nil {
+		return result, err
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 4 {
+			continue
+		}
+
+		date, err := time.Parse(time.RFC3339, fields[2])
+		if err != nil {
+			return result, err
+		}
+
+		result.Commits = append(result.Commits, commitInfo{
+			Hash:    fields[0],
+			Author:  fields[1],
+			Date:    date,
+			Subject: fields[3],
+		})
+	}
+
+	return result, nil
+}
+
+func printLog(results []call.Result[repoLog]) {
+	type entry struct {
+		repo string
+		commitInfo
+	}
+
+	var (
+		entries []entry
+		errs    []string
+	)
+
+	for _, result := range results {
+		if result.Err != nil {
+			errs = append(errs, fmt.Sprintf("ERROR: %s: %v", result.Repo, result.Err))
+			continue
+		}
+
+		for _, commit := range result.Value.Commits {
+			entries = append(entries, entry{result.Repo, commit})
+		}
+	}
+
+	// newest first, as in git log
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Date.After(entries[j].Date)
+	})
+
+	if logLimit > 0 && len(entries) > logLimit {
+		entries = entries[:logLimit]
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "REPOSITORY\tCOMMIT\tAUTHOR\tDATE\tSUBJECT")
+
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s\t%.8s\t%s\t%s\t%s\n", e.repo, e.Hash, e.Author, e.Date.Local().Format("2006-01-02 15:04"), e.Subject)
+	}
+
+	w.Flush()
+
+	for _, err := range errs {
+		fmt.Println(err)
+	}
+}
+
+func printChangelog(results []call.Result[repoLog]) {
+	fmt.Println("# Changelog")
+
+	for _, result := range results {
+		fmt.Printf("\n## %s\n\n", result.Repo)
+
+		if result.Err != nil {
+			fmt.Printf("_Error: %v_\n", result.Err)
+			continue
+		}
+
+		commits := result.Value.Commits
+		if logLimit > 0 && len(commits) > logLimit {
+			commits = commits[:logLimit]
+		}
+
+		if len(commits) == 0 {
+			fmt.Printf("_No changes in %s_\n", result.Value.Range)
+			continue
+		}
+
+		fmt.Printf("Changes in `%s`:\n\n", result.Value.Range)
+
+		for _, commit := range commits {
+			fmt.Printf("- %s (%.8s, %s)\n", commit.Subject, commit.Hash, commit.Author)
+		}
+	}
+}
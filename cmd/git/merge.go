@@ -0,0 +1,91 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/ryclarke/cisco-batch-tool/call"
+	"github.com/ryclarke/cisco-batch-tool/config"
+	"github.com/ryclarke/cisco-batch-tool/utils"
+)
+
+var mergeNoPush bool
+
+func addMergeDefaultCmd() *cobra.Command {
+	// mergeDefaultCmd represents the merge-default command
+	mergeDefaultCmd := &cobra.Command{
+		Use:   "merge-default <repository> ...",
+		Short: "Merge the source branch into feature branches across repositories",
+		Long: `Merge the source branch into feature branches across repositories
+
+The latest source branch from origin is merged into the current branch (or the branch
+given with '--branch') and the merge commit is pushed. This is an alternative to
+rebasing for repositories which forbid force-pushes. If the merge has conflicts, it
+is aborted and the conflicting files are listed, leaving the repository as it was.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if branch, _ := cmd.Flags().GetString("branch"); branch != "" {
+				viper.Set(config.Branch, branch)
+			}
+
+			results := newSummary("merged")
+			call.Do(args, call.Wrap(results.track(gitFetch, gitSwitch, utils.ValidateBranch, gitMergeDefault)))
+			results.print(args)
+		},
+	}
+
+	mergeDefaultCmd.Flags().StringP("branch", "b", "", "branch to merge into (default: current branch)")
+	mergeDefaultCmd.Flags().BoolVar(&mergeNoPush, "no-push", false, "don't push the merge commit")
+
+	return mergeDefaultCmd
+}
+
+func gitMergeDefault(name string, ch chan<- string) error {
+	dir, err := utils.RepoPath(name)
+	if err != nil {
+		return err
+	}
+
+	source := "origin/" + viper.GetString(config.SourceBranch)
+
+	cmd := exec.Command("git", "merge", "--no-edit", source)
+	cmd.Dir = dir
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		conflicts := conflictingFiles(name)
+		if len(conflicts) == 0 {
+			return fmt.Errorf("%w: %s", err, output)
+		}
+
+		abort := exec.Command("git", "merge", "--abort")
+		abort.Dir = dir
+
+		if output, err := abort.CombinedOutput(); err != nil {
+			return fmt.Errorf("merge --abort failed: %w: %s", err, output)
+		}
+
+		return fmt.Errorf("%w merging %s (merge aborted): %s", errConflict, source, strings.Join(conflicts, ", "))
+	}
+
+	ch <- string(output)
+
+	if mergeNoPush {
+		return nil
+	}
+
+	cmd = exec.Command("git", "push")
+	cmd.Dir = dir
+
+	if output, err = cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, output)
+	}
+
+	ch <- string(output)
+
+	return nil
+}
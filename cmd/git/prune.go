@@ -0,0 +1,242 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/ryclarke/cisco-batch-tool/call"
+	"github.com/ryclarke/cisco-batch-tool/config"
+	"github.com/ryclarke/cisco-batch-tool/utils"
+)
+
+var (
+	pruneRemote    bool
+	pruneDryRun    bool
+	pruneOlderThan time.Duration
+)
+
+func addPruneBranchesCmd() *cobra.Command {
+	// pruneBranchesCmd represents the prune-branches command
+	pruneBranchesCmd := &cobra.Command{
+		Use:   "prune-branches <repository> ...",
+		Short: "Delete merged and stale branches across repositories",
+		Long: `Delete merged and stale branches across repositories
+
+Local branches which are already merged into the source branch are deleted. The
+source branch and the current branch are never deleted.
+
+With '--remote', the corresponding branches on origin of the deleted local branches
+are also deleted if all of their pull requests have been merged or declined. Remote
+branches without any pull requests, or with commits which aren't part of the latest
+pull request, are kept.
+
+Use '--older-than' (e.g. 30d, 2w or 72h) to only delete branches whose latest
+commit is older than the given age.`,
+		Args: cobra.MinimumNArgs(1),
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			if age, _ := cmd.Flags().GetString("older-than"); age != "" {
+				var err error
+				if pruneOlderThan, err = utils.ParseAge(age); err != nil {
+					return err
+				}
+			}
+
+			if pruneRemote {
+				return utils.ValidateRequiredConfig(config.AuthToken)
+			}
+
+			return nil
+		},
+		Run: func(_ *cobra.Command, args []string) {
+			call.Do(args, call.Wrap(call.Exec("git", "fetch", "--prune", "origin"), pruneBranches))
+		},
+	}
+
+	pruneBranchesCmd.Flags().BoolVar(&pruneRemote, "remote", false, "also delete remote branches whose pull requests are merged or declined")
+	pruneBranchesCmd.Flags().BoolVar(&pruneDryRun, "dry-run", false, "list the branches which would be deleted without deleting them")
+	pruneBranchesCmd.Flags().String("older-than", "", "only delete branches with no commits within the given age")
+
+	return pruneBranchesCmd
+}
+
+// branchRef is a branch name along with the time of its latest commit
+type branchRef struct {
+	name      string
+	committed time.Time
+}
+
+// listBranches returns the branches matching the ref pattern, excluding protected branches
+func listBranches(name string, args ...string) ([]branchRef, error) {
+	dir, err := utils.RepoPath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	args = append([]string{"for-each-ref", "--format=%(refname:lstrip=2) %(committerdate:unix)"}, args...)
+
+	output, err := utils.GitOutput(dir, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := utils.GitOutput(dir, "branch", "--show-current")
+	if err != nil {
+		return nil, err
+	}
+
+	protected := map[string]bool{
+		viper.GetString(config.SourceBranch): true,
+		current:                              true,
+	}
+
+	var branches []branchRef
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || protected[fields[0]] {
+			continue
+		}
+
+		unix, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		branch := branchRef{name: fields[0], committed: time.Unix(unix, 0)}
+		if pruneOlderThan > 0 && time.Since(branch.committed) < pruneOlderThan {
+			continue
+		}
+
+		branches = append(branches, branch)
+	}
+
+	return branches, nil
+}
+
+// pruneBranches deletes the merged local branches and, with '--remote', their remote branches
+func pruneBranches(name string, ch chan<- string) error {
+	pruned, err := pruneLocalBranches(name, ch)
+	if err != nil || !pruneRemote {
+		return err
+	}
+
+	return pruneRemoteBranches(name, pruned, ch)
+}
+
+// pruneLocalBranches deletes the merged local branches and returns the names of those deleted
+func pruneLocalBranches(name string, ch chan<- string) ([]string, error) {
+	dir, err := utils.RepoPath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	branches, err := listBranches(name, "--merged", "origin/"+viper.GetString(config.SourceBranch), "refs/heads")
+	if err != nil {
+		return nil, err
+	}
+
+	var pruned []string
+
+	for _, branch := range branches {
+		if pruneDryRun {
+			ch <- fmt.Sprintf("Would delete merged branch %s (last commit %s)", branch.name, branch.committed.Format("2006-01-02"))
+			pruned = append(pruned, branch.name)
+
+			continue
+		}
+
+		cmd := exec.Command("git", "branch", "-d", branch.name)
+		cmd.Dir = dir
+
+		if output, err := cmd.CombinedOutput(); err != nil {
+			ch <- fmt.Sprintf("Skipping branch %s: %s", branch.name, strings.TrimSpace(string(output)))
+			continue
+		}
+
+		ch <- fmt.Sprintf("Deleted merged branch %s", branch.name)
+		pruned = append(pruned, branch.name)
+	}
+
+	return pruned, nil
+}
+
+// pruneRemoteBranches deletes the remote branches of the pruned local branches if all of
+// their pull requests are closed and the branch hasn't changed since the latest one
+func pruneRemoteBranches(name string, branches []string, ch chan<- string) error {
+	dir, err := utils.RepoPath(name)
+	if err != nil {
+		return err
+	}
+
+	var failed int
+
+	for _, branch := range branches {
+		// local branches without a remote branch have nothing to delete
+		tip, err := utils.GitOutput(dir, "rev-parse", "--verify", "--quiet", "refs/remotes/origin/"+branch)
+		if err != nil {
+			continue
+		}
+
+		prs, err := utils.GetPRs(name, branch, "ALL")
+		if err != nil {
+			ch <- fmt.Sprintf("Skipping remote branch %s: %v", branch, err)
+			failed++
+
+			continue
+		}
+
+		if !prsClosed(prs) {
+			continue
+		}
+
+		// commits pushed after the pull request was closed would be lost
+		if tip != prs[0].LatestCommit() {
+			ch <- fmt.Sprintf("Skipping remote branch %s: commits were pushed after PR %d", branch, prs[0].ID())
+			continue
+		}
+
+		if pruneDryRun {
+			ch <- fmt.Sprintf("Would delete remote branch %s (PR %s)", branch, prs[0].State())
+			continue
+		}
+
+		cmd := exec.Command("git", "push", "origin", "--delete", branch)
+		cmd.Dir = dir
+
+		if output, err := cmd.CombinedOutput(); err != nil {
+			ch <- fmt.Sprintf("Skipping remote branch %s: %s", branch, strings.TrimSpace(string(output)))
+			failed++
+
+			continue
+		}
+
+		ch <- fmt.Sprintf("Deleted remote branch %s (PR %s)", branch, prs[0].State())
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("could not prune %d remote branches", failed)
+	}
+
+	return nil
+}
+
+// prsClosed returns true if there are pull requests and all of them are merged or declined
+func prsClosed(prs []utils.PR) bool {
+	if len(prs) == 0 {
+		return false
+	}
+
+	for _, pr := range prs {
+		if pr.State() == "OPEN" {
+			return false
+		}
+	}
+
+	return true
+}
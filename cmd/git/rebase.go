@@ -0,0 +1,128 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/ryclarke/cisco-batch-tool/call"
+	"github.com/ryclarke/cisco-batch-tool/config"
+	"github.com/ryclarke/cisco-batch-tool/utils"
+)
+
+var rebasePush bool
+
+func addRebaseCmd() *cobra.Command {
+	// rebaseCmd represents the rebase command
+	rebaseCmd := &cobra.Command{
+		Use:   "rebase <repository> ...",
+		Short: "Rebase feature branches onto the source branch across repositories",
+		Long: `Rebase feature branches onto the source branch across repositories
+
+The current branch (or the branch given with '--branch') is rebased onto the latest
+source branch from origin. If the rebase has conflicts, it is aborted and the
+conflicting files are listed, leaving the repository as it was. Successfully rebased
+branches are force-pushed with '--force-with-lease' when '--push' is provided.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if branch, _ := cmd.Flags().GetString("branch"); branch != "" {
+				viper.Set(config.Branch, branch)
+			}
+
+			results := newSummary("rebased")
+			call.Do(args, call.Wrap(results.track(gitFetch, gitSwitch, utils.ValidateBranch, gitRebase)))
+			results.print(args)
+		},
+	}
+
+	rebaseCmd.Flags().StringP("branch", "b", "", "branch to rebase (default: current branch)")
+	rebaseCmd.Flags().BoolVarP(&rebasePush, "push", "p", false, "force-push rebased branches with --force-with-lease")
+
+	return rebaseCmd
+}
+
+// gitFetch updates the remote-tracking branches from origin
+func gitFetch(name string, ch chan<- string) error {
+	dir, err := utils.RepoPath(name)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command("git", "fetch", "origin")
+	cmd.Dir = dir
+
+	_, err = cmd.Output()
+
+	return err
+}
+
+// gitSwitch checks out the existing branch from the configuration, if any
+func gitSwitch(name string, ch chan<- string) error {
+	dir, err := utils.RepoPath(name)
+	if err != nil {
+		return err
+	}
+
+	branch := viper.GetString(config.Branch)
+	if branch == "" {
+		return nil
+	}
+
+	cmd := exec.Command("git", "checkout", branch)
+	cmd.Dir = dir
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, output)
+	}
+
+	return nil
+}
+
+func gitRebase(name string, ch chan<- string) error {
+	dir, err := utils.RepoPath(name)
+	if err != nil {
+		return err
+	}
+
+	source := "origin/" + viper.GetString(config.SourceBranch)
+
+	cmd := exec.Command("git", "rebase", source)
+	cmd.Dir = dir
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		conflicts := conflictingFiles(name)
+		if len(conflicts) == 0 {
+			return fmt.Errorf("%w: %s", err, output)
+		}
+
+		abort := exec.Command("git", "rebase", "--abort")
+		abort.Dir = dir
+
+		if output, err := abort.CombinedOutput(); err != nil {
+			return fmt.Errorf("rebase --abort failed: %w: %s", err, output)
+		}
+
+		return fmt.Errorf("%w rebasing onto %s (rebase aborted): %s", errConflict, source, strings.Join(conflicts, ", "))
+	}
+
+	ch <- string(output)
+
+	if !rebasePush {
+		return nil
+	}
+
+	cmd = exec.Command("git", "push", "--force-with-lease")
+	cmd.Dir = dir
+
+	if output, err = cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, output)
+	}
+
+	ch <- string(output)
+
+	return nil
+}
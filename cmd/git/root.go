@@ -20,8 +20,31 @@ func Cmd() *cobra.Command {
 		addCommitCmd(),
 		addDiffCmd(),
 		addUpdateCmd(),
+		addStashCmd(),
+		addRebaseCmd(),
+		addMergeDefaultCmd(),
+		addPruneBranchesCmd(),
+		addTagCmd(),
+		addLogCmd(),
 	)
 	rootCmd.Run = defaultCmd.Run
 
 	return rootCmd
 }
+
+// Update checks out and pulls the source branch of a repository.
+func Update(repo string, ch chan<- string) error {
+	return gitUpdate(repo, ch)
+}
+
+// Checkout checks out the configured branch of a repository, creating and pushing
+// it from the current branch if it doesn't exist yet.
+func Checkout(repo string, ch chan<- string) error {
+	return gitCheckout(repo, ch)
+}
+
+// Commit commits all changes of a repository with the configured commit message
+// and pushes them to the remote.
+func Commit(repo string, ch chan<- string) error {
+	return gitCommit(repo, ch)
+}
@@ -0,0 +1,231 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ryclarke/cisco-batch-tool/call"
+	"github.com/ryclarke/cisco-batch-tool/utils"
+)
+
+// autostashMessage identifies stashes created by the --autostash option
+const autostashMessage = "batch-tool autostash"
+
+var (
+	stashMessage   string
+	stashUntracked bool
+	stashYes       bool
+)
+
+func addStashCmd() *cobra.Command {
+	// stashCmd represents the git stash command
+	stashCmd := &cobra.Command{
+		Use:   "stash [cmd] <repository> ...",
+		Short: "Manage git stashes across repositories",
+		Args:  cobra.MinimumNArgs(1),
+	}
+
+	defaultCmd := &cobra.Command{
+		Use:   "list <repository> ...",
+		Short: "List stashes of each repository",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			call.Do(args, call.Wrap(call.Exec("git", "stash", "list")))
+		},
+	}
+
+	saveCmd := &cobra.Command{
+		Use:     "save <repository> ...",
+		Aliases: []string{"push"},
+		Short:   "Stash local changes across repositories",
+		Args:    cobra.MinimumNArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			stashArgs := []string{"stash", "push"}
+			if stashUntracked {
+				stashArgs = append(stashArgs, "--include-untracked")
+			}
+
+			if stashMessage != "" {
+				stashArgs = append(stashArgs, "-m", stashMessage)
+			}
+
+			call.Do(args, call.Wrap(call.Exec("git", stashArgs...)))
+		},
+	}
+
+	saveCmd.Flags().StringVarP(&stashMessage, "message", "m", "", "stash message")
+	saveCmd.Flags().BoolVarP(&stashUntracked, "include-untracked", "u", false, "also stash untracked files")
+
+	popCmd := &cobra.Command{
+		Use:   "pop <repository> ...",
+		Short: "Restore the latest stash across repositories",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			call.Do(args, call.Wrap(gitStashPop))
+		},
+	}
+
+	dropCmd := &cobra.Command{
+		Use:   "drop <repository> ...",
+		Short: "Discard the latest stash across repositories",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			if !stashYes {
+				fmt.Printf("Discarding the latest stash of: %v\n", args)
+
+				if confirmed, err := utils.Confirm("Are you sure?"); err != nil || !confirmed {
+					return err
+				}
+			}
+
+			call.Do(args, call.Wrap(call.Exec("git", "stash", "drop")))
+
+			return nil
+		},
+	}
+
+	dropCmd.Flags().BoolVarP(&stashYes, "yes", "y", false, "skip the confirmation prompt")
+
+	stashCmd.AddCommand(
+		defaultCmd,
+		saveCmd,
+		popCmd,
+		dropCmd,
+	)
+	stashCmd.Run = defaultCmd.Run
+
+	return stashCmd
+}
+
+// gitStashPop restores the latest stash, reporting any conflicting files
+func gitStashPop(name string, ch chan<- string) error {
+	dir, err := utils.RepoPath(name)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command("git", "stash", "pop")
+	cmd.Dir = dir
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if conflicts := conflictingFiles(name); len(conflicts) > 0 {
+			return fmt.Errorf("conflicts restoring stash (changes kept in stash): %s", strings.Join(conflicts, ", "))
+		}
+
+		return fmt.Errorf("%w: %s", err, output)
+	}
+
+	ch <- string(output)
+
+	return nil
+}
+
+// autostash wraps the provided CallFuncs, stashing any local changes beforehand and
+// restoring them afterwards. The stash is restored even if one of the calls fails. With
+// restoreBranch, the original branch is checked out again before the stash is restored,
+// and the stash is kept if that isn't possible.
+func autostash(restoreBranch bool, calls ...call.CallFunc) call.CallFunc {
+	return func(name string, ch chan<- string) error {
+		dir, err := utils.RepoPath(name)
+		if err != nil {
+			return err
+		}
+
+		status, err := utils.GitOutput(dir, "status", "--porcelain")
+		if err != nil {
+			return err
+		}
+
+		// nothing to stash, so there's nothing to restore either
+		if status == "" {
+			return runCalls(name, ch, calls...)
+		}
+
+		cmd := exec.Command("git", "stash", "push", "--include-untracked", "-m", autostashMessage)
+		cmd.Dir = dir
+
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("%w: %s", err, output)
+		}
+
+		ch <- "Stashed local changes\n"
+
+		// a detached HEAD is returned to by commit instead
+		original, err := utils.GitOutput(dir, "branch", "--show-current")
+		if err == nil && original == "" {
+			original, err = utils.GitOutput(dir, "rev-parse", "HEAD")
+		}
+
+		if err != nil {
+			return fmt.Errorf("%w (changes kept in stash)", err)
+		}
+
+		callErr := runCalls(name, ch, calls...)
+
+		// only restore the stash created above, in case something else was stashed since
+		top, err := utils.GitOutput(dir, "stash", "list", "-1", "--format=%s")
+		if err != nil {
+			return err
+		}
+
+		if !strings.HasSuffix(top, autostashMessage) {
+			return fmt.Errorf("autostash is no longer the latest stash - restore it manually")
+		}
+
+		if restoreBranch {
+			cmd := exec.Command("git", "checkout", original)
+			cmd.Dir = dir
+
+			if output, err := cmd.CombinedOutput(); err != nil {
+				err = fmt.Errorf("could not return to %s (changes kept in stash): %w: %s", original, err, output)
+				if callErr != nil {
+					return fmt.Errorf("%v; %v", callErr, err)
+				}
+
+				return err
+			}
+		}
+
+		if err := gitStashPop(name, ch); err != nil {
+			if callErr != nil {
+				return fmt.Errorf("%v; %v", callErr, err)
+			}
+
+			return err
+		}
+
+		ch <- "Restored local changes\n"
+
+		return callErr
+	}
+}
+
+// runCalls executes each CallFunc in order, stopping if an error is encountered
+func runCalls(name string, ch chan<- string, calls ...call.CallFunc) error {
+	for _, call := range calls {
+		if err := call(name, ch); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// conflictingFiles returns the list of unmerged files in the repository
+func conflictingFiles(name string) []string {
+	dir, err := utils.RepoPath(name)
+	if err != nil {
+		return nil
+	}
+
+	output, err := utils.GitOutput(dir, "diff", "--name-only", "--diff-filter=U")
+	if err != nil || output == "" {
+		return nil
+	}
+
+	return strings.Split(output, "\n")
+}
@@ -1,9 +1,24 @@
 package git
 
 import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 
 	"github.com/ryclarke/cisco-batch-tool/call"
+	"github.com/ryclarke/cisco-batch-tool/config"
+	"github.com/ryclarke/cisco-batch-tool/utils"
+)
+
+var (
+	statusTable bool
+	statusJSON  bool
 )
 
 func addStatusCmd() *cobra.Command {
@@ -11,11 +26,204 @@ func addStatusCmd() *cobra.Command {
 	statusCmd := &cobra.Command{
 		Use:   "status <repository> ...",
 		Short: "Git status of each repository",
-		Args:  cobra.MinimumNArgs(1),
+		Long: `Git status of each repository
+
+With '--table' or '--json', the status of each repository is summarized in a single
+report instead: the current branch, commits ahead/behind its upstream and the source
+branch, counts of staged, unstaged, conflicting and untracked files, the number of
+stashes, and the pull request for the branch (if an auth token is available).`,
+		Args: cobra.MinimumNArgs(1),
 		Run: func(_ *cobra.Command, args []string) {
-			call.Do(args, call.Wrap(call.Exec("git", "-c", "color.status=always", "status", "-sb")))
+			if !statusTable && !statusJSON {
+				call.Do(args, call.Wrap(call.Exec("git", "-c", "color.status=always", "status", "-sb")))
+				return
+			}
+
+			results := call.Gather(args, gatherStatus)
+
+			if statusJSON {
+				printStatusJSON(results)
+			} else {
+				printStatusTable(results)
+			}
 		},
 	}
 
+	statusCmd.Flags().BoolVar(&statusTable, "table", false, "summarize the status of all repositories in a table")
+	statusCmd.Flags().BoolVar(&statusJSON, "json", false, "summarize the status of all repositories as JSON")
+
 	return statusCmd
 }
+
+// repoStatus summarizes the state of a single repository
+type repoStatus struct {
+	Repository    string `json:"repository"`
+	Branch        string `json:"branch"`
+	Upstream      string `json:"upstream,omitempty"`
+	Ahead         int    `json:"ahead"`
+	Behind        int    `json:"behind"`
+	DefaultAhead  int    `json:"default_ahead"`
+	DefaultBehind int    `json:"default_behind"`
+	Staged        int    `json:"staged"`
+	Unstaged      int    `json:"unstaged"`
+	Conflicts     int    `json:"conflicts"`
+	Untracked     int    `json:"untracked"`
+	Stashes       int    `json:"stashes"`
+	// PullRequest is the ID of the branch pull request (0 if there is none),
+	// or nil if pull requests could not be checked.
+	PullRequest *int   `json:"pull_request"`
+	Error       string `json:"error,omitempty"`
+}
+
+func gatherStatus(repo string) (repoStatus, error) {
+	status := repoStatus{Repository: repo}
+
+	if err := utils.ValidatePath(repo); err != nil {
+		return status, err
+	}
+
+	dir, err := utils.RepoPath(repo)
+	if err != nil {
+		return status, err
+	}
+
+	output, err := utils.GitOutput(dir, "status", "--porcelain=v2", "--branch")
+	if err != nil {
+		return status, err
+	}
+
+	parseStatus(&status, output)
+
+	output, err = utils.GitOutput(dir, "stash", "list")
+	if err != nil {
+		return status, err
+	}
+
+	if output != "" {
+		status.Stashes = len(strings.Split(output, "\n"))
+	}
+
+	// the source branch may not have been fetched, so this is best-effort
+	source := "origin/" + viper.GetString(config.SourceBranch)
+	if output, err := utils.GitOutput(dir, "rev-list", "--left-right", "--count", "HEAD..."+source); err == nil {
+		if counts := strings.Fields(output); len(counts) == 2 {
+			status.DefaultAhead, _ = strconv.Atoi(counts[0])
+			status.DefaultBehind, _ = strconv.Atoi(counts[1])
+		}
+	}
+
+	if viper.GetString(config.AuthToken) != "" && !viper.GetBool(config.Offline) &&
+		status.Branch != viper.GetString(config.SourceBranch) && status.Branch != "(detached)" {
+		if pr, err := utils.GetPR(repo, status.Branch); err == nil {
+			id := pr.ID()
+			status.PullRequest = &id
+		}
+	}
+
+	return status, nil
+}
+
+// parseStatus populates the status from the output of `git status --porcelain=v2 --branch`
+func parseStatus(status *repoStatus, output string) {
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "#":
+			if len(fields) < 3 {
+				continue
+			}
+
+			switch fields[1] {
+			case "branch.head":
+				status.Branch = fields[2]
+			case "branch.upstream":
+				status.Upstream = fields[2]
+			case "branch.ab":
+				if len(fields) == 4 {
+					status.Ahead, _ = strconv.Atoi(strings.TrimPrefix(fields[2], "+"))
+					status.Behind, _ = strconv.Atoi(strings.TrimPrefix(fields[3], "-"))
+				}
+			}
+		case "1", "2":
+			// the XY field holds the staged and unstaged states, where '.' is unmodified
+			if len(fields) > 1 && len(fields[1]) == 2 {
+				if fields[1][0] != '.' {
+					status.Staged++
+				}
+
+				if fields[1][1] != '.' {
+					status.Unstaged++
+				}
+			}
+		case "u":
+			status.Conflicts++
+		case "?":
+			status.Untracked++
+		}
+	}
+}
+
+func printStatusTable(results []call.Result[repoStatus]) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintf(w, "REPOSITORY\tBRANCH\tUPSTREAM\t%s\tSTAGED\tUNSTAGED\tCONFLICTS\tUNTRACKED\tSTASHES\tPR\n",
+		strings.ToUpper(viper.GetString(config.SourceBranch)))
+
+	var errs []string
+
+	for _, result := range results {
+		if result.Err != nil {
+			errs = append(errs, fmt.Sprintf("ERROR: %s: %v", result.Repo, result.Err))
+			continue
+		}
+
+		s := result.Value
+
+		upstream := "-"
+		if s.Upstream != "" {
+			upstream = fmt.Sprintf("+%d -%d", s.Ahead, s.Behind)
+		}
+
+		pr := "-"
+		if s.PullRequest != nil {
+			pr = "none"
+			if *s.PullRequest > 0 {
+				pr = fmt.Sprintf("#%d", *s.PullRequest)
+			}
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t+%d -%d\t%d\t%d\t%d\t%d\t%d\t%s\n",
+			s.Repository, s.Branch, upstream, s.DefaultAhead, s.DefaultBehind,
+			s.Staged, s.Unstaged, s.Conflicts, s.Untracked, s.Stashes, pr)
+	}
+
+	w.Flush()
+
+	// errors are listed separately to keep the table aligned
+	for _, err := range errs {
+		fmt.Println(err)
+	}
+}
+
+func printStatusJSON(results []call.Result[repoStatus]) {
+	statuses := make([]repoStatus, len(results))
+
+	for i, result := range results {
+		statuses[i] = result.Value
+		if result.Err != nil {
+			statuses[i].Error = result.Err.Error()
+		}
+	}
+
+	output, err := json.MarshalIndent(statuses, "", "  ")
+	if err != nil {
+		fmt.Println("ERROR:", err)
+		return
+	}
+
+	fmt.Println(string(output))
+}
@@ -0,0 +1,77 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ryclarke/cisco-batch-tool/call"
+	"github.com/ryclarke/cisco-batch-tool/catalog"
+)
+
+// errConflict is wrapped by errors which leave the repository unchanged due to conflicts
+var errConflict = errors.New("conflicts")
+
+// summary records the outcome of an operation on each repository for a final report
+type summary struct {
+	mu       sync.Mutex
+	success  string
+	outcomes map[string][]string
+}
+
+func newSummary(success string) *summary {
+	return &summary{
+		success:  success,
+		outcomes: make(map[string][]string),
+	}
+}
+
+// track wraps the provided CallFuncs to record their combined outcome
+func (s *summary) track(calls ...call.CallFunc) call.CallFunc {
+	return func(name string, ch chan<- string) error {
+		err := runCalls(name, ch, calls...)
+
+		outcome := s.success
+
+		switch {
+		case errors.Is(err, errConflict):
+			outcome = "conflicting"
+		case err != nil:
+			outcome = "failed"
+		}
+
+		s.mu.Lock()
+		s.outcomes[outcome] = append(s.outcomes[outcome], name)
+		s.mu.Unlock()
+
+		return err
+	}
+}
+
+// print writes the repositories for each recorded outcome. Repositories matching the
+// filters without a recorded outcome failed before reaching the tracked calls.
+func (s *summary) print(filters []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	missing := catalog.RepositoryList(filters...)
+	for _, outcome := range s.outcomes {
+		missing.RemoveAll(outcome...)
+	}
+
+	s.outcomes["failed"] = append(s.outcomes["failed"], missing.ToSlice()...)
+
+	fmt.Println("Summary:")
+
+	for _, outcome := range []string{s.success, "conflicting", "failed"} {
+		repos := s.outcomes[outcome]
+		if len(repos) == 0 {
+			continue
+		}
+
+		sort.Strings(repos)
+		fmt.Printf("  %s (%d): %s\n", outcome, len(repos), strings.Join(repos, ", "))
+	}
+}
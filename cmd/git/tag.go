@@ -0,0 +1,231 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/ryclarke/cisco-batch-tool/call"
+	"github.com/ryclarke/cisco-batch-tool/config"
+	"github.com/ryclarke/cisco-batch-tool/utils"
+)
+
+var (
+	tagVersion       string
+	tagBump          string
+	tagMessage       string
+	tagRef           string
+	tagReleaseBranch bool
+	tagYes           bool
+)
+
+func addTagCmd() *cobra.Command {
+	// tagCmd represents the tag command
+	tagCmd := &cobra.Command{
+		Use:     "tag <repository> ...",
+		Aliases: []string{"release"},
+		Short:   "Create and push release tags across repositories",
+		Long: `Create and push release tags across repositories
+
+An annotated tag is created on the latest source branch from origin (or the ref given
+with '--ref') and pushed. The version is either given explicitly with '--version', or
+computed per repository by bumping the latest semantic version tag with '--bump'.
+
+A preview of the current and new version of each repository is shown for confirmation
+before anything is changed. With '--release-branch', a 'release/<version>' branch is
+also created from the same ref.`,
+		Args: cobra.MinimumNArgs(1),
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			if (tagVersion == "") == (tagBump == "") {
+				return fmt.Errorf("exactly one of --version or --bump is required")
+			}
+
+			if tagVersion != "" {
+				if _, err := utils.ParseVersion(tagVersion); err != nil {
+					return err
+				}
+			}
+
+			if tagBump != "" {
+				if _, err := (utils.Version{}).Bump(tagBump); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+		RunE: func(_ *cobra.Command, args []string) error {
+			results := call.Gather(args, planTag)
+
+			versions := make(map[string]string, len(results))
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "REPOSITORY\tCURRENT\tNEW")
+
+			var errs []string
+
+			for _, result := range results {
+				if result.Err != nil {
+					errs = append(errs, fmt.Sprintf("ERROR: %s: %v", result.Repo, result.Err))
+					continue
+				}
+
+				versions[result.Repo] = result.Value.next
+				fmt.Fprintf(w, "%s\t%s\t%s\n", result.Repo, result.Value.current, result.Value.next)
+			}
+
+			w.Flush()
+
+			for _, err := range errs {
+				fmt.Println(err)
+			}
+
+			if len(versions) == 0 {
+				return fmt.Errorf("no repositories to tag")
+			}
+
+			if !tagYes {
+				if confirmed, err := utils.Confirm("\nCreate and push these tags?"); err != nil || !confirmed {
+					return err
+				}
+			}
+
+			repos := make([]string, 0, len(versions))
+			for repo := range versions {
+				repos = append(repos, repo)
+			}
+
+			call.Do(repos, call.Wrap(gitTag(versions)))
+
+			return nil
+		},
+	}
+
+	tagCmd.Flags().StringVar(&tagVersion, "version", "", "explicit version to tag (e.g. v1.2.0)")
+	tagCmd.Flags().StringVar(&tagBump, "bump", "", "bump the latest version tag (major, minor or patch)")
+	tagCmd.Flags().StringVarP(&tagMessage, "message", "m", "", "tag message (default: Release <version>)")
+	tagCmd.Flags().StringVar(&tagRef, "ref", "", "ref to tag (default: origin/<source branch>)")
+	tagCmd.Flags().BoolVar(&tagReleaseBranch, "release-branch", false, "also create a release/<version> branch")
+	tagCmd.Flags().BoolVarP(&tagYes, "yes", "y", false, "skip the confirmation prompt")
+
+	return tagCmd
+}
+
+// tagPlan is the current and next version of a repository
+type tagPlan struct {
+	current string
+	next    string
+}
+
+func planTag(repo string) (tagPlan, error) {
+	plan := tagPlan{current: "-"}
+
+	if err := utils.ValidatePath(repo); err != nil {
+		return plan, err
+	}
+
+	dir, err := utils.RepoPath(repo)
+	if err != nil {
+		return plan, err
+	}
+
+	if _, err := utils.GitOutput(dir, "fetch", "--tags", "origin"); err != nil {
+		return plan, err
+	}
+
+	latest, err := utils.LatestVersion(repo)
+	if err != nil {
+		return plan, err
+	}
+
+	if latest != nil {
+		plan.current = latest.String()
+	}
+
+	if tagVersion != "" {
+		plan.next = tagVersion
+	} else {
+		// the first release is bumped from v0.0.0
+		base := utils.Version{Prefix: "v"}
+		if latest != nil {
+			base = *latest
+		}
+
+		next, err := base.Bump(tagBump)
+		if err != nil {
+			return plan, err
+		}
+
+		plan.next = next.String()
+	}
+
+	if _, err := utils.GitOutput(dir, "rev-parse", "--verify", "--quiet", "refs/tags/"+plan.next); err == nil {
+		return plan, fmt.Errorf("tag %s already exists", plan.next)
+	}
+
+	return plan, nil
+}
+
+// gitTag creates a CallFunc which tags each repository with its planned version
+func gitTag(versions map[string]string) call.CallFunc {
+	return func(name string, ch chan<- string) error {
+		dir, err := utils.RepoPath(name)
+		if err != nil {
+			return err
+		}
+
+		version := versions[name]
+
+		ref := tagRef
+		if ref == "" {
+			ref = "origin/" + viper.GetString(config.SourceBranch)
+		}
+
+		message := tagMessage
+		if message == "" {
+			message = "Release " + version
+		}
+
+		if err := runGit(dir, ch, "tag", "-a", version, "-m", message, ref); err != nil {
+			return err
+		}
+
+		// don't leave a local tag behind which would be reported as already existing next time
+		if err := runGit(dir, ch, "push", "origin", "refs/tags/"+version); err != nil {
+			if _, delErr := utils.GitOutput(dir, "tag", "-d", version); delErr != nil {
+				return fmt.Errorf("%v; could not delete local tag %s: %v", err, version, delErr)
+			}
+
+			return fmt.Errorf("%v (local tag %s deleted)", err, version)
+		}
+
+		if tagReleaseBranch {
+			if err := runGit(dir, ch, "push", "origin", ref+":refs/heads/release/"+version); err != nil {
+				return err
+			}
+		}
+
+		ch <- fmt.Sprintf("Tagged %s at %s", version, ref)
+
+		return nil
+	}
+}
+
+// runGit runs git in the given directory, sending its output to the channel
+func runGit(dir string, ch chan<- string, args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, output)
+	}
+
+	ch <- string(output)
+
+	return nil
+}
@@ -17,25 +17,37 @@ func addUpdateCmd() *cobra.Command {
 		Use:   "update <repository> ...",
 		Short: "Update primary branch across repositories",
 		Args:  cobra.MinimumNArgs(1),
-		Run: func(_ *cobra.Command, args []string) {
+		Run: func(cmd *cobra.Command, args []string) {
+			if stash, _ := cmd.Flags().GetBool("autostash"); stash {
+				call.Do(args, call.Wrap(autostash(true, gitUpdate)))
+				return
+			}
+
 			call.Do(args, call.Wrap(gitUpdate))
 		},
 	}
 
+	updateCmd.Flags().Bool("autostash", false, "stash local changes before updating and restore them afterwards")
+
 	return updateCmd
 }
 
 func gitUpdate(repo string, ch chan<- string) error {
+	dir, err := utils.RepoPath(repo)
+	if err != nil {
+		return err
+	}
+
 	cmd := exec.Command("git", "checkout", viper.GetString(config.SourceBranch))
-	cmd.Dir = utils.RepoPath(repo)
+	cmd.Dir = dir
 
-	_, err := cmd.Output()
+	_, err = cmd.Output()
 	if err != nil {
 		return err
 	}
 
 	cmd = exec.Command("git", "pull")
-	cmd.Dir = utils.RepoPath(repo)
+	cmd.Dir = dir
 
 	output, err := cmd.Output()
 	if err != nil {
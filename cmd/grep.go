@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ryclarke/cisco-batch-tool/call"
+	"github.com/ryclarke/cisco-batch-tool/utils"
+)
+
+var (
+	grepIgnoreCase bool
+	grepFixed      bool
+	grepExtended   bool
+	grepWord       bool
+	grepPaths      []string
+	grepFiles      bool
+	grepCount      bool
+	grepRepos      bool
+	grepJSON       bool
+)
+
+func addGrepCmd() *cobra.Command {
+	// grepCmd represents the grep command
+	grepCmd := &cobra.Command{
+		Use:   "grep <pattern> <repository> ...",
+		Short: "Search tracked files across repositories",
+		Long: `Search tracked files across repositories
+
+The pattern is searched with 'git grep' in each repository in parallel, and the
+matches are aggregated in repository order as 'repo:path:line: text'.
+
+With '--repos', only the names of repositories with a match are printed, one per
+line, so they can be passed to another batch command:
+
+  batch-tool git branch -b fix-deprecated $(batch-tool -q grep --repos OldFunc ~all)`,
+		Args: cobra.MinimumNArgs(2),
+		Run: func(_ *cobra.Command, args []string) {
+			pattern := args[0]
+
+			results := call.Gather(args[1:], func(repo string) ([]grepMatch, error) {
+				return gitGrep(repo, pattern)
+			})
+
+			switch {
+			case grepJSON:
+				printGrepJSON(results)
+			case grepRepos:
+				printGrepRepos(results)
+			default:
+				printGrep(results)
+			}
+		},
+	}
+
+	grepCmd.Flags().BoolVarP(&grepIgnoreCase, "ignore-case", "i", false, "ignore case differences")
+	grepCmd.Flags().BoolVarP(&grepFixed, "fixed-strings", "F", false, "interpret the pattern as a literal string")
+	grepCmd.Flags().BoolVarP(&grepExtended, "extended-regexp", "E", false, "interpret the pattern as an extended regular expression")
+	grepCmd.Flags().BoolVarP(&grepWord, "word-regexp", "w", false, "only match whole words")
+	grepCmd.Flags().StringSliceVarP(&grepPaths, "path", "p", nil, "limit the search to matching pathspecs (e.g. '*.go')")
+	grepCmd.Flags().BoolVarP(&grepFiles, "files-with-matches", "l", false, "only show the paths of matching files")
+	grepCmd.Flags().BoolVarP(&grepCount, "count", "c", false, "only show the number of matches per file")
+	grepCmd.Flags().BoolVar(&grepRepos, "repos", false, "only show the names of matching repositories")
+	grepCmd.Flags().BoolVar(&grepJSON, "json", false, "show the matches as JSON")
+
+	return grepCmd
+}
+
+// grepMatch is a single matching line in a repository
+type grepMatch struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+func gitGrep(repo, pattern string) ([]grepMatch, error) {
+	dir, err := utils.RepoPath(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := utils.ValidatePath(repo); err != nil {
+		return nil, err
+	}
+
+	args := []string{"grep", "-n", "-I", "--null", "--no-color"}
+
+	if grepIgnoreCase {
+		args = append(args, "-i")
+	}
+
+	if grepFixed {
+		args = append(args, "-F")
+	}
+
+	if grepExtended {
+		args = append(args, "-E")
+	}
+
+	if grepWord {
+		args = append(args, "-w")
+	}
+
+	args = append(args, "-e", pattern, "--")
+	args = append(args, grepPaths...)
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+
+	output, err := cmd.Output()
+	if err != nil {
+		// git grep exits with status 1 when nothing matched
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 && len(exitErr.Stderr) == 0 {
+			return nil, nil
+		}
+
+		if errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+
+		return nil, err
+	}
+
+	var matches []grepMatch
+
+	for _, line := range strings.Split(strings.TrimRight(string(output), "\n"), "\n") {
+		// path, line number and text are separated by NUL
+		fields := strings.SplitN(line, "\x00", 3)
+		if len(fields) != 3 {
+			continue
+		}
+
+		num, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, err
+		}
+
+		matches = append(matches, grepMatch{Path: fields[0], Line: num, Text: fields[2]})
+	}
+
+	return matches, nil
+}
+
+func printGrep(results []call.Result[[]grepMatch]) {
+	var errs []string
+
+	for _, result := range results {
+		if result.Err != nil {
+			errs = append(errs, fmt.Sprintf("ERROR: %s: %v", result.Repo, result.Err))
+			continue
+		}
+
+		if !grepFiles && !grepCount {
+			for _, match := range result.Value {
+				fmt.Printf("%s:%s:%d: %s\n", result.Repo, match.Path, match.Line, match.Text)
+			}
+
+			continue
+		}
+
+		// matches are grouped by file in the order reported by git grep
+		var paths []string
+		counts := make(map[string]int)
+
+		for _, match := range result.Value {
+			if counts[match.Path] == 0 {
+				paths = append(paths, match.Path)
+			}
+
+			counts[match.Path]++
+		}
+
+		for _, path := range paths {
+			if grepCount {
+				fmt.Printf("%s:%s:%d\n", result.Repo, path, counts[path])
+			} else {
+				fmt.Printf("%s:%s\n", result.Repo, path)
+			}
+		}
+	}
+
+	for _, err := range errs {
+		fmt.Println(err)
+	}
+}
+
+func printGrepRepos(results []call.Result[[]grepMatch]) {
+	for _, result := range results {
+		// keep stdout limited to repository names so it can be used as arguments
+		if result.Err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %s: %v\n", result.Repo, result.Err)
+			continue
+		}
+
+		if len(result.Value) > 0 {
+			fmt.Println(result.Repo)
+		}
+	}
+}
+
+func printGrepJSON(results []call.Result[[]grepMatch]) {
+	type repoMatches struct {
+		Repository string      `json:"repository"`
+		Count      int         `json:"count"`
+		Matches    []grepMatch `json:"matches"`
+		Error      string      `json:"error,omitempty"`
+	}
+
+	report := make([]repoMatches, 0, len(results))
+
+	for _, result := range results {
+		entry := repoMatches{
+			Repository: result.Repo,
+			Count:      len(result.Value),
+			Matches:    result.Value,
+		}
+
+		if entry.Matches == nil {
+			entry.Matches = []grepMatch{}
+		}
+
+		if result.Err != nil {
+			entry.Error = result.Err.Error()
+		}
+
+		report = append(report, entry)
+	}
+
+	output, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		fmt.Println("ERROR:", err)
+		return
+	}
+
+	fmt.Println(string(output))
+}
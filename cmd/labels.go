@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/ryclarke/cisco-batch-tool/catalog"
+	"github.com/ryclarke/cisco-batch-tool/config"
+	"github.com/ryclarke/cisco-batch-tool/utils"
+)
+
+func addLabelsAddCmd() *cobra.Command {
+	// labelsAddCmd represents the labels add command
+	labelsAddCmd := &cobra.Command{
+		Use:     "add <label> <repository> ...",
+		Short:   "Add a label to repositories",
+		Args:    cobra.MinimumNArgs(2),
+		PreRunE: validateLabelsRemote,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			local, err := cmd.Flags().GetBool("local")
+			if err != nil {
+				return err
+			}
+
+			return updateLabel(args[0], selectAllRepos(args[1:]), true, local)
+		},
+	}
+
+	labelsAddCmd.Flags().Bool("local", false, "add to a local alias in the config file instead of a remote label")
+
+	return labelsAddCmd
+}
+
+func addLabelsRemoveCmd() *cobra.Command {
+	// labelsRemoveCmd represents the labels remove command
+	labelsRemoveCmd := &cobra.Command{
+		Use:     "remove <label> <repository> ...",
+		Aliases: []string{"rm"},
+		Short:   "Remove a label from repositories",
+		Args:    cobra.MinimumNArgs(2),
+		PreRunE: validateLabelsRemote,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			local, err := cmd.Flags().GetBool("local")
+			if err != nil {
+				return err
+			}
+
+			return updateLabel(args[0], selectAllRepos(args[1:]), false, local)
+		},
+	}
+
+	labelsRemoveCmd.Flags().Bool("local", false, "remove from a local alias in the config file instead of a remote label")
+
+	return labelsRemoveCmd
+}
+
+func addLabelsSyncCmd() *cobra.Command {
+	// labelsSyncCmd represents the labels sync command
+	labelsSyncCmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Synchronize remote labels with local aliases",
+		Long: `Synchronize remote labels with local aliases
+
+Each alias defined in the config file is created as a remote label on every
+repository it lists. With '--prune', the label is also removed from any
+repository which is not listed in the alias.`,
+		Args: cobra.NoArgs,
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			return utils.ValidateRequiredConfig(config.AuthToken)
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			prune, err := cmd.Flags().GetBool("prune")
+			if err != nil {
+				return err
+			}
+
+			aliases := viper.GetStringMapStringSlice(config.RepoAliases)
+
+			names := make([]string, 0, len(aliases))
+			for name := range aliases {
+				names = append(names, name)
+			}
+
+			sort.Strings(names)
+
+			var failed int
+
+			for _, label := range names {
+				wanted := mapset.NewSet[string]()
+
+				for _, repo := range aliases[label] {
+					if _, ok := catalog.Catalog[repo]; !ok {
+						fmt.Printf("WARNING: %s is not in the catalog - skipping\n", repo)
+						continue
+					}
+
+					wanted.Add(repo)
+				}
+
+				if err := updateLabel(label, sortedSlice(wanted), true, false); err != nil {
+					failed++
+				}
+
+				if !prune {
+					continue
+				}
+
+				unwanted := mapset.NewSet[string]()
+
+				for name := range catalog.Catalog {
+					if catalog.HasLabel(name, label) && !wanted.Contains(name) {
+						unwanted.Add(name)
+					}
+				}
+
+				if err := updateLabel(label, sortedSlice(unwanted), false, false); err != nil {
+					failed++
+				}
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("failed to synchronize %d label(s)", failed)
+			}
+
+			return nil
+		},
+	}
+
+	labelsSyncCmd.Flags().Bool("from-config", false, "create remote labels from the aliases in the config file")
+	labelsSyncCmd.MarkFlagRequired("from-config")
+
+	labelsSyncCmd.Flags().Bool("prune", false, "remove remote labels from repositories not listed in the alias")
+
+	return labelsSyncCmd
+}
+
+// validateLabelsRemote requires an auth token unless only local aliases are modified
+func validateLabelsRemote(cmd *cobra.Command, _ []string) error {
+	if local, _ := cmd.Flags().GetBool("local"); local {
+		return nil
+	}
+
+	return utils.ValidateRequiredConfig(config.AuthToken)
+}
+
+// updateLabel adds or removes the label for each repository, either remotely or as a local alias
+func updateLabel(label string, repos []string, add, local bool) error {
+	if local {
+		return updateAlias(label, repos, add)
+	}
+
+	var failed int
+
+	for _, repo := range repos {
+		var err error
+
+		if add {
+			err = catalog.AddLabel(repo, label)
+		} else {
+			err = catalog.RemoveLabel(repo, label)
+		}
+
+		switch {
+		case err != nil:
+			fmt.Printf("ERROR: %s: %v\n", repo, err)
+			failed++
+		case add:
+			fmt.Printf("Added label %s to %s\n", label, repo)
+		default:
+			fmt.Printf("Removed label %s from %s\n", label, repo)
+		}
+	}
+
+	// keep the cache consistent with the remote labels, even after partial failure
+	if err := catalog.SaveCache(); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to update label %s on %d repositories", label, failed)
+	}
+
+	return nil
+}
+
+// updateAlias adds or removes the repositories from the local alias in the config file
+func updateAlias(label string, repos []string, add bool) error {
+	aliases := viper.GetStringMapStringSlice(config.RepoAliases)
+	set := mapset.NewSet[string](aliases[label]...)
+
+	if add {
+		set.Append(repos...)
+	} else {
+		set.RemoveAll(repos...)
+	}
+
+	if set.Cardinality() > 0 {
+		aliases[label] = sortedSlice(set)
+	} else {
+		delete(aliases, label)
+	}
+
+	if err := config.Save(config.RepoAliases, aliases); err != nil {
+		return err
+	}
+
+	fmt.Printf("Updated alias %s: %v\n", label, aliases[label])
+
+	return nil
+}
+
+// selectRepos expands the given filters into a sorted list of repositories
+func selectRepos(filters []string) []string {
+	return sortedSlice(catalog.RepositoryList(filters...))
+}
+
+// selectAllRepos expands the given filters like selectRepos, including repositories
+// with unwanted labels
+func selectAllRepos(filters []string) []string {
+	return sortedSlice(catalog.RepositoryListAll(filters...))
+}
+
+func sortedSlice(set mapset.Set[string]) []string {
+	list := set.ToSlice()
+	sort.Strings(list)
+
+	return list
+}
@@ -24,7 +24,7 @@ func addNewCmd() *cobra.Command {
 		Short: "Submit new pull requests",
 		Args:  cobra.MinimumNArgs(1),
 		Run: func(_ *cobra.Command, args []string) {
-			call.Do(args, call.Wrap(utils.ValidateBranch, newPR))
+			call.Do(args, call.Wrap(utils.ValidateBranch, New(prTitle, prDescription, allReviewers)))
 		},
 	}
 
@@ -33,15 +33,23 @@ func addNewCmd() *cobra.Command {
 	return newCmd
 }
 
-func newPR(name string, ch chan<- string) error {
+// New returns a CallFunc which submits a new pull request for the current branch of
+// a repository. Only the first reviewer is added unless allReviewers is set.
+func New(title, description string, allReviewers bool) call.CallFunc {
+	return func(name string, ch chan<- string) error {
+		return newPR(name, ch, title, description, allReviewers)
+	}
+}
+
+func newPR(name string, ch chan<- string, title, description string, allReviewers bool) error {
 	branch, err := utils.LookupBranch(name)
 	if err != nil {
 		return err
 	}
 
 	// default PR title is branch name
-	if prTitle == "" {
-		prTitle = branch
+	if title == "" {
+		title = branch
 	}
 
 	reviewers := utils.LookupReviewers(name)
@@ -55,7 +63,7 @@ func newPR(name string, ch chan<- string) error {
 		reviewers = reviewers[:1]
 	}
 
-	payload := utils.GenPR(name, prTitle, prDescription, reviewers)
+	payload := utils.GenPR(name, title, description, reviewers)
 
 	request, err := http.NewRequest(http.MethodPost, utils.ApiPath(name), strings.NewReader(payload))
 	if err != nil {
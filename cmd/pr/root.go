@@ -1,9 +1,7 @@
 package pr
 
 import (
-	"encoding/json"
 	"fmt"
-	"net/http"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -67,32 +65,14 @@ func getPR(name string) (utils.PR, error) {
 		return nil, err
 	}
 
-	request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s?direction=outgoing&at=refs/heads/%s", utils.ApiPath(name), branch), nil)
+	pr, err := utils.GetPR(name, branch)
 	if err != nil {
 		return nil, err
 	}
 
-	request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", viper.GetString(config.AuthToken)))
-	request.Header.Set("Content-Type", "application/json")
-
-	resp, err := http.DefaultClient.Do(request)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	// results will be returned in an array property called "values"
-	raw := struct {
-		Values []utils.PR `json:"values"`
-	}{}
-	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
-		return nil, err
-	}
-
-	if len(raw.Values) == 0 {
+	if pr == nil {
 		return nil, fmt.Errorf("No pull requests found for %s", branch)
 	}
 
-	// return the first PR in the results (this will be the most recent)
-	return raw.Values[0], nil
+	return pr, nil
 }
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ryclarke/cisco-batch-tool/call"
+	"github.com/ryclarke/cisco-batch-tool/utils"
+)
+
+var (
+	replaceRegex bool
+	replaceGlobs []string
+)
+
+func addReplaceCmd() *cobra.Command {
+	// replaceCmd represents the replace command
+	replaceCmd := &cobra.Command{
+		Use:   "replace <search> <replacement> <repository> ...",
+		Short: "Search and replace in files across repositories",
+		Long: `Search and replace in files across repositories
+
+Each occurrence of the search string is replaced in the tracked files of each
+repository, limited to the files matching the '--glob' pathspecs if provided. With
+'--regex', the search string is a regular expression and the replacement may refer
+to submatches (e.g. '${1}'). A unified diff of the changes is shown for each
+repository; with '--dry-run', the files are not modified.
+
+The change can be carried through the usual branch, commit and pull request flow
+in the same invocation: '--branch' checks out the branch from the updated source
+branch before replacing, '--message' commits and pushes the changes, and '--pr'
+submits a new pull request. Repositories without any matches are left untouched.`,
+		Args:    cobra.MinimumNArgs(3),
+		PreRunE: validateChangeFlags,
+		Run: func(cmd *cobra.Command, args []string) {
+			search, replacement := args[0], args[1]
+
+			var re *regexp.Regexp
+			if replaceRegex {
+				var err error
+				if re, err = regexp.Compile(search); err != nil {
+					fmt.Printf("ERROR: invalid regular expression: %v\n", err)
+					return
+				}
+			}
+
+			setChangeConfig(cmd)
+
+			replacer := func(content []byte) []byte {
+				if re != nil {
+					return re.ReplaceAll(content, []byte(replacement))
+				}
+
+				return bytes.ReplaceAll(content, []byte(search), []byte(replacement))
+			}
+
+			call.Do(args[2:], call.Wrap(applyChange(func(repo string) ([]fileChange, error) {
+				return planReplace(repo, replaceGlobs, replacer)
+			})))
+		},
+	}
+
+	replaceCmd.Flags().BoolVarP(&replaceRegex, "regex", "E", false, "interpret the search string as a regular expression")
+	replaceCmd.Flags().StringSliceVarP(&replaceGlobs, "glob", "g", nil, "limit the replacement to files matching the pathspecs (e.g. '*.go')")
+
+	addChangeFlags(replaceCmd)
+
+	return replaceCmd
+}
+
+// planReplace applies the replacer to the content of each tracked file of the repository
+// matching the pathspecs and returns the files which would be changed
+func planReplace(repo string, globs []string, replacer func([]byte) []byte) ([]fileChange, error) {
+	dir, err := utils.RepoPath(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := utils.GitOutput(dir, append([]string{"ls-files", "-z", "--"}, globs...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []fileChange
+
+	for _, path := range strings.Split(output, "\x00") {
+		if path == "" {
+			continue
+		}
+
+		file := filepath.Join(dir, path)
+
+		// skip tracked files deleted from the working tree, symlinks and submodules
+		info, err := os.Lstat(file)
+		if os.IsNotExist(err) || (err == nil && !info.Mode().IsRegular()) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		// skip binary files
+		if bytes.IndexByte(content, 0) >= 0 {
+			continue
+		}
+
+		if replaced := replacer(content); !bytes.Equal(content, replaced) {
+			changes = append(changes, fileChange{path: path, mode: info.Mode().Perm(), old: content, new: replaced})
+		}
+	}
+
+	return changes, nil
+}
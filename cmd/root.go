@@ -10,6 +10,7 @@ import (
 	"github.com/ryclarke/cisco-batch-tool/catalog"
 	"github.com/ryclarke/cisco-batch-tool/cmd/git"
 	"github.com/ryclarke/cisco-batch-tool/cmd/pr"
+	"github.com/ryclarke/cisco-batch-tool/cmd/workspace"
 	"github.com/ryclarke/cisco-batch-tool/config"
 )
 
@@ -44,18 +45,23 @@ multiple git repositories, including branch management and pull request creation
 				fmt.Println(config.Version)
 			},
 		},
-		&cobra.Command{
-			Use:   "catalog",
-			Short: "Print information on the cached repository catalog",
-			Run: func(_ *cobra.Command, _ []string) {
-				fmt.Printf("%v\n", catalog.Catalog)
-			},
-		},
 		git.Cmd(),
 		pr.Cmd(),
+		workspace.Cmd(),
+		addCatalogCmd(),
 		addMakeCmd(),
+		addTaskCmd(),
+		addTestCmd(),
 		addShellCmd(),
+		addCloneCmd(),
+		addSyncCmd(),
+		addCredentialCmd(),
 		addLabelsCmd(),
+		addGrepCmd(),
+		addReplaceCmd(),
+		addCampaignCmd(),
+		addFilesCmd(),
+		addDepsCmd(),
 	)
 
 	rootCmd.PersistentFlags().StringVar(&config.CfgFile, "config", "", "config file (default is .config.yaml)")
@@ -63,6 +69,18 @@ multiple git repositories, including branch management and pull request creation
 	rootCmd.PersistentFlags().Bool("sync", false, "execute commands synchronously")
 	viper.BindPFlag(config.UseSync, rootCmd.PersistentFlags().Lookup("sync"))
 
+	rootCmd.PersistentFlags().IntP("max-parallel", "j", 0, "maximum number of repositories to process at once (default: unlimited)")
+	viper.BindPFlag(config.MaxParallel, rootCmd.PersistentFlags().Lookup("max-parallel"))
+
+	rootCmd.PersistentFlags().Bool("offline", false, "use the cached catalog without contacting the remote host")
+	viper.BindPFlag(config.Offline, rootCmd.PersistentFlags().Lookup("offline"))
+
+	rootCmd.PersistentFlags().String("worktree", "", "operate on the worktree of the given branch instead of the primary clone")
+	viper.BindPFlag(config.Worktree, rootCmd.PersistentFlags().Lookup("worktree"))
+
+	rootCmd.PersistentFlags().BoolP("quiet", "q", false, "suppress informational output")
+	viper.BindPFlag(config.Quiet, rootCmd.PersistentFlags().Lookup("quiet"))
+
 	rootCmd.PersistentFlags().Bool("sort", true, "sort the provided repositories")
 	viper.BindPFlag(config.SortRepos, rootCmd.PersistentFlags().Lookup("sort"))
 
@@ -83,10 +101,21 @@ multiple git repositories, including branch management and pull request creation
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the RootCmd.
 func Execute() {
-	cobra.OnInitialize(config.Init, catalog.Init)
+	cobra.OnInitialize(initialize)
 
 	if err := RootCmd().Execute(); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
 }
+
+// initialize loads the configuration and the repository catalog, exiting if the
+// configuration is unusable
+func initialize() {
+	if err := config.Init(); err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		os.Exit(1)
+	}
+
+	catalog.Init()
+}
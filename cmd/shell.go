@@ -1,14 +1,12 @@
 package cmd
 
 import (
-	"bufio"
 	"fmt"
-	"os"
-	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/ryclarke/cisco-batch-tool/call"
+	"github.com/ryclarke/cisco-batch-tool/utils"
 )
 
 func addShellCmd() *cobra.Command {
@@ -29,24 +27,9 @@ func addShellCmd() *cobra.Command {
 			// DOUBLE CHECK with the user before running anything!
 			fmt.Printf("Executing command: %v\n", args)
 			fmt.Printf("  sh -c \"%s\"\n", exec)
-			fmt.Printf("Are you sure? ")
 
-			var confirm string
-
-			for confirm != "yes" && confirm != "no" {
-				fmt.Printf("[yes/no] ")
-
-				confirm, err = bufio.NewReader(os.Stdin).ReadString('\n')
-				if err != nil {
-					return err
-				}
-
-				// strip the trailing newline and make lower
-				confirm = strings.TrimSpace(strings.ToLower(confirm))
-			}
-
-			if confirm == "no" {
-				return nil
+			if confirmed, err := utils.Confirm("Are you sure?"); err != nil || !confirmed {
+				return err
 			}
 
 			call.Do(args, call.Wrap(call.Exec("sh", "-c", exec)))
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/ryclarke/cisco-batch-tool/call"
+	"github.com/ryclarke/cisco-batch-tool/catalog"
+	"github.com/ryclarke/cisco-batch-tool/cmd/workspace"
+)
+
+func addSyncCmd() *cobra.Command {
+	// syncCmd represents the sync command
+	syncCmd := &cobra.Command{
+		Use:   "sync [repository ...]",
+		Short: "Synchronize the workspace with the repository catalog",
+		Long: `Synchronize the workspace with the repository catalog
+
+Repositories missing from the workspace are cloned and existing clones are fetched.
+All repositories in the catalog are synchronized unless a filter is provided.
+
+Local clones of repositories which no longer exist in the catalog are reported, and
+are removed with '--prune' unless they contain uncommitted changes, unpushed commits
+or stashes.`,
+		PreRun: clonePreRun,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			prune, err := cmd.Flags().GetBool("prune")
+			if err != nil {
+				return err
+			}
+
+			if len(args) == 0 {
+				args = []string{"~all"}
+			}
+
+			call.Do(args, call.Wrap(call.Exec("git", "fetch", "--prune")))
+
+			// stale clones are those which no longer exist in the catalog at all
+			keep := mapset.NewSet[string]()
+			for name := range catalog.Catalog {
+				keep.Add(name)
+			}
+
+			return workspace.Prune(keep, nil, "", !prune)
+		},
+	}
+
+	syncCmd.Flags().Bool("prune", false, "remove local clones which no longer exist in the catalog")
+	addCloneFlags(syncCmd)
+
+	return syncCmd
+}
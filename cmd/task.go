@@ -0,0 +1,233 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"text/template"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/ryclarke/cisco-batch-tool/call"
+	"github.com/ryclarke/cisco-batch-tool/config"
+	"github.com/ryclarke/cisco-batch-tool/utils"
+)
+
+var taskTargets []string
+
+func addTaskCmd() *cobra.Command {
+	// taskCmd represents the task command
+	taskCmd := &cobra.Command{
+		Use:     "task <repository> ...",
+		Aliases: []string{"build"},
+		Short:   "Run logical build targets with each repository's build system",
+		Long: `Run logical build targets with each repository's build system
+
+The build system of each repository is detected from its marker files (Makefile,
+magefile, package.json, go.mod, ...) in the order configured in 'build.order', and
+each logical target (format, lint, test, build, ...) is mapped to a command for that
+build system by the 'build.systems.<system>.targets' templates, where '*' matches
+any target. Targets which the build system doesn't define are skipped, and the
+result of each target is summarized once all repositories are done.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			results := &taskResults{results: make(map[string][]taskResult)}
+
+			call.Do(args, call.Wrap(runTasks(taskTargets, results)))
+
+			results.print()
+		},
+	}
+
+	taskCmd.Flags().StringSliceVarP(&taskTargets, "target", "t", []string{"format"}, "logical target(s), such as format, lint, test or build")
+
+	return taskCmd
+}
+
+// Task result statuses
+const (
+	taskOK      = "ok"
+	taskFailed  = "failed"
+	taskSkipped = "skipped"
+)
+
+type taskResult struct {
+	system string
+	target string
+	status string
+	reason string
+}
+
+// taskResults collects the result of each target across repositories
+type taskResults struct {
+	mu      sync.Mutex
+	repos   []string
+	results map[string][]taskResult
+}
+
+func (r *taskResults) add(repo string, result taskResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.results[repo]; !ok {
+		r.repos = append(r.repos, repo)
+	}
+
+	r.results[repo] = append(r.results[repo], result)
+}
+
+func (r *taskResults) print() {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "REPOSITORY\tSYSTEM\tTARGET\tSTATUS")
+
+	// repositories finish in any order
+	sort.Strings(r.repos)
+
+	for _, repo := range r.repos {
+		for _, result := range r.results[repo] {
+			status := result.status
+			if result.reason != "" {
+				status += " (" + result.reason + ")"
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", repo, result.system, result.target, status)
+		}
+	}
+
+	w.Flush()
+}
+
+// runTasks returns a CallFunc which runs each target with the build system of a repository
+func runTasks(targets []string, results *taskResults) call.CallFunc {
+	return func(repo string, ch chan<- string) error {
+		if err := utils.ValidatePath(repo); err != nil {
+			results.add(repo, taskResult{system: "-", target: "-", status: taskFailed, reason: err.Error()})
+			return err
+		}
+
+		system, err := detectBuildSystem(repo)
+		if err != nil {
+			results.add(repo, taskResult{system: "-", target: "-", status: taskFailed, reason: err.Error()})
+			return err
+		}
+
+		if system == "" {
+			ch <- "Skipping: no build system detected\n"
+			results.add(repo, taskResult{system: "-", target: "-", status: taskSkipped, reason: "no build system"})
+
+			return nil
+		}
+
+		for _, target := range targets {
+			result := taskResult{system: system, target: target}
+
+			err := runTask(repo, system, target, ch)
+
+			switch {
+			case errors.Is(err, errNoTarget):
+				ch <- fmt.Sprintf("Skipping %s: not defined for %s\n", target, system)
+				result.status, result.reason = taskSkipped, "not defined"
+			case err != nil:
+				ch <- fmt.Sprintf("ERROR: %s: %v\n", target, err)
+				result.status, result.reason = taskFailed, err.Error()
+			default:
+				result.status = taskOK
+			}
+
+			results.add(repo, result)
+		}
+
+		return nil
+	}
+}
+
+var errNoTarget = errors.New("target not defined")
+
+// runTask runs a single target, or returns errNoTarget if the build system doesn't define it
+func runTask(repo, system, target string, ch chan<- string) error {
+	key := fmt.Sprintf("%s.%s.targets.", config.BuildSystems, system)
+
+	tmpl := viper.GetString(key + target)
+	if tmpl == "" {
+		tmpl = viper.GetString(key + "*")
+	}
+
+	if tmpl == "" {
+		return errNoTarget
+	}
+
+	if check := viper.GetString(fmt.Sprintf("%s.%s.check", config.BuildSystems, system)); check != "" {
+		command, err := renderTask(check, target)
+		if err != nil {
+			return err
+		}
+
+		if _, err := runShell(repo, command); err != nil {
+			return errNoTarget
+		}
+	}
+
+	command, err := renderTask(tmpl, target)
+	if err != nil {
+		return err
+	}
+
+	ch <- fmt.Sprintf("$ %s\n", command)
+
+	output, err := runShell(repo, command)
+	ch <- string(output)
+
+	return err
+}
+
+// detectBuildSystem returns the first configured build system with a marker file in the repository
+func detectBuildSystem(repo string) (string, error) {
+	dir, err := utils.RepoPath(repo)
+	if err != nil {
+		return "", err
+	}
+
+	for _, system := range viper.GetStringSlice(config.BuildOrder) {
+		for _, file := range viper.GetStringSlice(fmt.Sprintf("%s.%s.files", config.BuildSystems, system)) {
+			if _, err := os.Stat(filepath.Join(dir, file)); err == nil {
+				return system, nil
+			}
+		}
+	}
+
+	return "", nil
+}
+
+func renderTask(text, target string) (string, error) {
+	tmpl, err := template.New("task").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, struct{ Target string }{target}); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// runShell runs the command with sh in the repository and returns its combined output
+func runShell(repo, command string) ([]byte, error) {
+	dir, err := utils.RepoPath(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Dir = dir
+
+	return cmd.CombinedOutput()
+}
@@ -0,0 +1,448 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/ryclarke/cisco-batch-tool/call"
+	"github.com/ryclarke/cisco-batch-tool/config"
+	"github.com/ryclarke/cisco-batch-tool/utils"
+)
+
+var (
+	testJUnit   string
+	testJSON    bool
+	testVerbose bool
+)
+
+func addTestCmd() *cobra.Command {
+	// testCmd represents the test command
+	testCmd := &cobra.Command{
+		Use:   "test <repository> ...",
+		Short: "Run tests across repositories and aggregate the results",
+		Long: `Run tests across repositories and aggregate the results
+
+The test command ('go test -json ./...' by default) is run in each repository,
+and its test events are parsed into a single report: the number of passed, failed
+and skipped tests and the test duration of each repository, followed by the names
+of all failing tests. With '--verbose', the output of failing tests is included.
+
+With '--junit', the results are also written to a JUnit XML file with one test
+suite per package of each repository.`,
+		Args:         cobra.MinimumNArgs(1),
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, args []string) error {
+			results := call.Gather(args, runTests)
+
+			if testJUnit != "" {
+				if err := writeJUnit(testJUnit, results); err != nil {
+					return err
+				}
+			}
+
+			if testJSON {
+				if err := printTestJSON(results); err != nil {
+					return err
+				}
+			} else {
+				printTestReport(results)
+			}
+
+			for _, result := range results {
+				if result.Err != nil || result.Value.Failed > 0 {
+					return fmt.Errorf("tests failed")
+				}
+			}
+
+			return nil
+		},
+	}
+
+	testCmd.Flags().String("command", "", "test command producing 'go test -json' output (default: go test -json ./...)")
+	viper.BindPFlag(config.TestCommand, testCmd.Flags().Lookup("command"))
+
+	testCmd.Flags().StringVar(&testJUnit, "junit", "", "write the results to a JUnit XML file")
+	testCmd.Flags().BoolVar(&testJSON, "json", false, "print the results as JSON")
+	testCmd.Flags().BoolVarP(&testVerbose, "verbose", "v", false, "include the output of failing tests")
+
+	return testCmd
+}
+
+// testEvent is a single event of 'go test -json'
+type testEvent struct {
+	Action      string
+	Package     string
+	Test        string
+	Elapsed     float64
+	Output      string
+	ImportPath  string
+	FailedBuild string
+}
+
+// testRun is the aggregated test results of a repository
+type testRun struct {
+	Passed  int `json:"passed"`
+	Failed  int `json:"failed"`
+	Skipped int `json:"skipped"`
+	// Elapsed is the total duration of the tests in seconds
+	Elapsed float64    `json:"elapsed"`
+	Tests   []testCase `json:"tests"`
+}
+
+// testCase is the result of a single test, or of a package which failed outside of a test
+type testCase struct {
+	Package string  `json:"package"`
+	Name    string  `json:"name"`
+	Status  string  `json:"status"`
+	Elapsed float64 `json:"elapsed"`
+	Output  string  `json:"output,omitempty"`
+}
+
+func runTests(repo string) (testRun, error) {
+	var run testRun
+
+	if err := utils.ValidatePath(repo); err != nil {
+		return run, err
+	}
+
+	dir, err := utils.RepoPath(repo)
+	if err != nil {
+		return run, err
+	}
+
+	cmd := exec.Command("sh", "-c", viper.GetString(config.TestCommand))
+	cmd.Dir = dir
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	// the command fails when any test fails, which is only an error if no failure was reported
+	output, cmdErr := cmd.Output()
+
+	type key struct{ pkg, test string }
+
+	outputs := make(map[key]*strings.Builder)
+
+	// parent tests summarize their subtests, so only leaf tests are counted, unless a
+	// parent fails by itself; packages are likewise only counted if no test failed
+	parents := make(map[key]bool)
+	failedChild := make(map[key]bool)
+	failedPackages := make(map[string]bool)
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		var event testEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil || event.Action == "" {
+			continue
+		}
+
+		k := key{event.Package, event.Test}
+
+		switch event.Action {
+		case "output", "build-output":
+			// build output is reported for the package being built, and referenced by its failure
+			if event.Action == "build-output" {
+				k = key{pkg: event.ImportPath}
+			}
+
+			if outputs[k] == nil {
+				outputs[k] = &strings.Builder{}
+			}
+
+			outputs[k].WriteString(event.Output)
+		case "pass", "fail", "skip":
+			if event.Test == "" {
+				// package results summarize their tests, except for failures outside of a test
+				run.Elapsed += event.Elapsed
+				if event.Action == "fail" && !failedPackages[event.Package] {
+					out := builderString(outputs[key{pkg: event.FailedBuild}]) + builderString(outputs[k])
+					run.Tests = append(run.Tests, testCase{Package: event.Package, Status: "fail", Elapsed: event.Elapsed, Output: out})
+					run.Failed++
+				}
+
+				continue
+			}
+
+			for _, parent := range parentTests(event.Test) {
+				parents[key{event.Package, parent}] = true
+				if event.Action == "fail" {
+					failedChild[key{event.Package, parent}] = true
+				}
+			}
+
+			if parents[k] && (event.Action != "fail" || failedChild[k]) {
+				continue
+			}
+
+			if event.Action == "fail" {
+				failedPackages[event.Package] = true
+			}
+
+			tc := testCase{Package: event.Package, Name: event.Test, Status: event.Action, Elapsed: event.Elapsed}
+
+			switch event.Action {
+			case "pass":
+				run.Passed++
+			case "fail":
+				run.Failed++
+				tc.Output = builderString(outputs[k])
+			case "skip":
+				run.Skipped++
+			}
+
+			run.Tests = append(run.Tests, tc)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return run, err
+	}
+
+	if cmdErr != nil && run.Failed == 0 {
+		return run, fmt.Errorf("%w: %s", cmdErr, strings.TrimSpace(stderr.String()))
+	}
+
+	return run, nil
+}
+
+// parentTests returns the names of the parent tests of a subtest, e.g. TestA and
+// TestA/b for TestA/b/c
+func parentTests(test string) []string {
+	var parents []string
+
+	for i := range test {
+		if test[i] == '/' {
+			parents = append(parents, test[:i])
+		}
+	}
+
+	return parents
+}
+
+func builderString(b *strings.Builder) string {
+	if b == nil {
+		return ""
+	}
+
+	return b.String()
+}
+
+func printTestReport(results []call.Result[testRun]) {
+	var total testRun
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "REPOSITORY\tPASS\tFAIL\tSKIP\tDURATION")
+
+	for _, result := range results {
+		if result.Err != nil {
+			fmt.Fprintf(w, "%s\t-\t-\t-\t-\n", result.Repo)
+			continue
+		}
+
+		run := result.Value
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s\n", result.Repo, run.Passed, run.Failed, run.Skipped, duration(run.Elapsed))
+
+		total.Passed += run.Passed
+		total.Failed += run.Failed
+		total.Skipped += run.Skipped
+		total.Elapsed += run.Elapsed
+	}
+
+	fmt.Fprintf(w, "TOTAL\t%d\t%d\t%d\t%s\n", total.Passed, total.Failed, total.Skipped, duration(total.Elapsed))
+	w.Flush()
+
+	for _, result := range results {
+		if result.Err != nil {
+			fmt.Printf("ERROR: %s: %v\n", result.Repo, result.Err)
+		}
+	}
+
+	var failed bool
+
+	for _, result := range results {
+		for _, tc := range result.Value.Tests {
+			if tc.Status != "fail" {
+				continue
+			}
+
+			if !failed {
+				fmt.Println("\nFailed tests:")
+				failed = true
+			}
+
+			name := tc.Package
+			if tc.Name != "" {
+				name += "." + tc.Name
+			}
+
+			fmt.Printf("  %s: %s (%s)\n", result.Repo, name, duration(tc.Elapsed))
+
+			if testVerbose && tc.Output != "" {
+				for _, line := range strings.Split(strings.TrimRight(tc.Output, "\n"), "\n") {
+					fmt.Printf("      %s\n", line)
+				}
+			}
+		}
+	}
+}
+
+func printTestJSON(results []call.Result[testRun]) error {
+	type repoRun struct {
+		Repository string `json:"repository"`
+		testRun
+		Error string `json:"error,omitempty"`
+	}
+
+	report := make([]repoRun, len(results))
+
+	for i, result := range results {
+		report[i] = repoRun{Repository: result.Repo, testRun: result.Value}
+		if result.Err != nil {
+			report[i].Error = result.Err.Error()
+		}
+
+		if !testVerbose {
+			for j := range report[i].Tests {
+				report[i].Tests[j].Output = ""
+			}
+		}
+	}
+
+	output, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(output))
+
+	return nil
+}
+
+type junitSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Errors   int         `xml:"errors,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnit writes the results as JUnit XML with a test suite per package of each repository
+func writeJUnit(path string, results []call.Result[testRun]) error {
+	var report junitSuites
+
+	for _, result := range results {
+		if result.Err != nil {
+			report.Suites = append(report.Suites, junitSuite{
+				Name:   result.Repo,
+				Tests:  1,
+				Errors: 1,
+				Time:   "0",
+				Cases: []junitCase{{
+					ClassName: result.Repo,
+					Name:      "test",
+					Time:      "0",
+					Error:     &junitMessage{Message: "test command failed", Text: result.Err.Error()},
+				}},
+			})
+
+			continue
+		}
+
+		suites := make(map[string]*junitSuite)
+		var names []string
+
+		for _, tc := range result.Value.Tests {
+			suite, ok := suites[tc.Package]
+			if !ok {
+				suite = &junitSuite{Name: result.Repo + "/" + tc.Package}
+				suites[tc.Package] = suite
+				names = append(names, tc.Package)
+			}
+
+			jc := junitCase{ClassName: tc.Package, Name: tc.Name, Time: seconds(tc.Elapsed)}
+
+			switch tc.Status {
+			case "fail":
+				suite.Failures++
+				jc.Failure = &junitMessage{Message: "failed", Text: tc.Output}
+
+				// failures outside of a test (e.g. build errors) are reported for the package
+				if tc.Name == "" {
+					jc.Name = "package"
+				}
+			case "skip":
+				suite.Skipped++
+				jc.Skipped = &junitMessage{Message: "skipped"}
+			}
+
+			suite.Tests++
+			suite.Cases = append(suite.Cases, jc)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			suite := suites[name]
+
+			var total float64
+			for _, tc := range result.Value.Tests {
+				if tc.Package == name && tc.Name != "" {
+					total += tc.Elapsed
+				}
+			}
+
+			suite.Time = seconds(total)
+			report.Suites = append(report.Suites, *suite)
+		}
+	}
+
+	output, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append([]byte(xml.Header), append(output, '\n')...), 0644)
+}
+
+func seconds(elapsed float64) string {
+	return fmt.Sprintf("%.3f", elapsed)
+}
+
+func duration(elapsed float64) time.Duration {
+	return time.Duration(elapsed * float64(time.Second)).Round(time.Millisecond)
+}
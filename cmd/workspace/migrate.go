@@ -0,0 +1,310 @@
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/ryclarke/cisco-batch-tool/config"
+	"github.com/ryclarke/cisco-batch-tool/utils"
+)
+
+// addMigrateCmd initializes the workspace migrate command
+func addMigrateCmd() *cobra.Command {
+	migrateCmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Move existing clones to a new workspace layout",
+		Long: `Move existing clones to a new workspace layout
+
+Every repository cloned under the current layout is moved to the path given by the
+new workspace root and/or path template, and the config file is updated to match.
+Path templates may reference {{.Root}}, {{.Host}}, {{.Project}} and {{.Name}}, and
+the repository name must be the final path element.
+
+With '--project', only the layout of the given project is changed and the result is
+saved as a per-project override. Otherwise, projects with an override of their own
+keep the overridden root and/or template.`,
+		Args: cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			if !cmd.Flags().Changed("root") && !cmd.Flags().Changed("template") {
+				return fmt.Errorf("at least one of --root or --template is required")
+			}
+
+			// the new layout must be saved, so fail before moving anything
+			if dryRun, _ := cmd.Flags().GetBool("dry-run"); !dryRun && viper.ConfigFileUsed() == "" {
+				return fmt.Errorf("no config file in use - provide one with --config")
+			}
+
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			root, _ := cmd.Flags().GetString("root")
+			tmpl, _ := cmd.Flags().GetString("template")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+			project, _ := cmd.Flags().GetString("project")
+			override := project != ""
+
+			if root != "" {
+				if abs, err := filepath.Abs(root); err == nil {
+					root = abs
+				}
+			}
+
+			var target *template.Template
+
+			if tmpl != "" {
+				var err error
+				if target, err = config.ParseTemplate(tmpl); err != nil {
+					return fmt.Errorf("invalid template: %w", err)
+				}
+			}
+
+			change := layoutChange{project: project, override: override, root: root, template: target}
+
+			if err := migrate(change, dryRun); err != nil {
+				return err
+			}
+
+			if dryRun {
+				return nil
+			}
+
+			return saveLayout(project, override, root, tmpl)
+		},
+	}
+
+	migrateCmd.Flags().String("root", "", "new workspace root directory")
+	migrateCmd.Flags().String("template", "", "new repository path template")
+	migrateCmd.Flags().String("project", "", "only migrate the given project, saving a per-project override")
+	migrateCmd.Flags().Bool("dry-run", false, "print the planned moves without changing anything")
+
+	return migrateCmd
+}
+
+// layoutChange is the new workspace root and/or template, applied either globally or as
+// an override of the project
+type layoutChange struct {
+	project  string
+	override bool
+	root     string
+	template *template.Template
+}
+
+// layouts returns the current and target layouts of the project. A global change doesn't
+// apply to the parts of the layout which the project overrides.
+func (c layoutChange) layouts(project string) (current, target utils.Layout, err error) {
+	if current, err = utils.ProjectLayout(project); err != nil {
+		return current, target, err
+	}
+
+	target = current
+	key := fmt.Sprintf("%s.%s", config.WorkspaceProjects, strings.ToLower(project))
+
+	if c.root != "" && (c.override || viper.GetString(key+".root") == "") {
+		target.Root = c.root
+	}
+
+	if c.template != nil && (c.override || viper.GetString(key+".template") == "") {
+		target.Template = c.template
+	}
+
+	return current, target, nil
+}
+
+// migrate moves the local clones from the current to the target layout of their project.
+// With an override, only the clones of the project are moved.
+func migrate(change layoutChange, dryRun bool) error {
+	host := viper.GetString(config.GitHost)
+
+	clones, err := utils.WorkspaceClones()
+	if err != nil {
+		return err
+	}
+
+	var failed int
+
+	for _, clone := range clones {
+		if change.override {
+			if !strings.EqualFold(clone.Project, change.project) {
+				continue
+			}
+
+			// overrides are keyed by the lowercase project, so keep the provided one instead
+			clone.Project = change.project
+		}
+
+		if err := migrateClone(host, clone, change, dryRun); err != nil {
+			fmt.Printf("ERROR: %s: %v\n", clone.Name, err)
+			failed++
+		}
+	}
+
+	// bring the catalog cache along so that it doesn't need to be fetched again
+	cacheProject := viper.GetString(config.GitProject)
+	if !change.override || strings.EqualFold(cacheProject, change.project) {
+		if err := migrateCache(host, cacheProject, change, dryRun); err != nil {
+			fmt.Printf("ERROR: catalog cache: %v\n", err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to migrate %d path(s) - the config file was not updated", failed)
+	}
+
+	return nil
+}
+
+// migrateCache moves the catalog cache of the project, if any, to the target layout
+func migrateCache(host, project string, change layoutChange, dryRun bool) error {
+	current, target, err := change.layouts(project)
+	if err != nil {
+		return err
+	}
+
+	oldDir, err := current.Path(host, project, "_")
+	if err != nil {
+		return err
+	}
+
+	newDir, err := target.Path(host, project, "_")
+	if err != nil {
+		return err
+	}
+
+	cacheFile := viper.GetString(config.CatalogCacheFile)
+	oldCache := filepath.Join(filepath.Dir(oldDir), cacheFile)
+	newCache := filepath.Join(filepath.Dir(newDir), cacheFile)
+
+	if _, err := os.Stat(oldCache); err != nil {
+		return nil
+	}
+
+	return move(oldCache, newCache, dryRun)
+}
+
+// migrateClone moves a single clone to the target layout, along with the worktrees next
+// to it, and repairs the links between the clone and all of its worktrees
+func migrateClone(host string, clone utils.Clone, change layoutChange, dryRun bool) error {
+	_, target, err := change.layouts(clone.Project)
+	if err != nil {
+		return err
+	}
+
+	newPath, err := target.Path(host, clone.Project, clone.Name)
+	if err != nil {
+		return err
+	}
+
+	if newPath == clone.Path {
+		return nil
+	}
+
+	worktrees, err := utils.LinkedWorktrees(clone.Path)
+	if err != nil {
+		return err
+	}
+
+	// check every destination before moving anything
+	moves := map[string]string{clone.Path: newPath}
+
+	for _, worktree := range worktrees {
+		if suffix, ok := strings.CutPrefix(worktree, clone.Path+"@"); ok && !strings.ContainsRune(suffix, filepath.Separator) {
+			moves[worktree] = newPath + "@" + suffix
+		}
+	}
+
+	for _, dest := range moves {
+		if _, err := os.Stat(dest); err == nil {
+			return fmt.Errorf("destination %s already exists", dest)
+		}
+	}
+
+	if err := move(clone.Path, newPath, dryRun); err != nil {
+		return err
+	}
+
+	var failed []string
+
+	for i, worktree := range worktrees {
+		dest, ok := moves[worktree]
+		if !ok {
+			continue
+		}
+
+		if err := move(worktree, dest, dryRun); err != nil {
+			failed = append(failed, worktree)
+			continue
+		}
+
+		worktrees[i] = dest
+	}
+
+	if !dryRun && len(worktrees) > 0 {
+		cmd := exec.Command("git", append([]string{"worktree", "repair"}, worktrees...)...)
+		cmd.Dir = newPath
+
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("%w: %s", err, output)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to move worktrees: %s", strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+func move(oldPath, newPath string, dryRun bool) error {
+	if oldPath == newPath {
+		return nil
+	}
+
+	if _, err := os.Stat(newPath); err == nil {
+		return fmt.Errorf("destination %s already exists", newPath)
+	}
+
+	fmt.Printf("%s -> %s\n", oldPath, newPath)
+
+	if dryRun {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(newPath), 0755); err != nil {
+		return err
+	}
+
+	return os.Rename(oldPath, newPath)
+}
+
+// saveLayout records the new layout in the config file, either globally or for the project
+func saveLayout(project string, override bool, root, tmpl string) error {
+	rootKey, tmplKey := config.WorkspaceRoot, config.WorkspaceTemplate
+
+	if override {
+		rootKey = fmt.Sprintf("%s.%s.root", config.WorkspaceProjects, project)
+		tmplKey = fmt.Sprintf("%s.%s.template", config.WorkspaceProjects, project)
+	}
+
+	if root != "" {
+		if err := config.Save(rootKey, root); err != nil {
+			return err
+		}
+	}
+
+	if tmpl != "" {
+		if err := config.Save(tmplKey, tmpl); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,154 @@
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/ryclarke/cisco-batch-tool/catalog"
+	"github.com/ryclarke/cisco-batch-tool/utils"
+)
+
+// addPruneCmd initializes the workspace prune command
+func addPruneCmd() *cobra.Command {
+	pruneCmd := &cobra.Command{
+		Use:   "prune [repository ...]",
+		Short: "Remove or archive local clones which are not in the catalog",
+		Long: `Remove or archive local clones which are not in the catalog
+
+Local clones anywhere in the workspace are removed if they don't belong to a repository
+in the catalog. Repositories with unwanted labels are excluded from the catalog as usual,
+so their clones are pruned as well. If repositories are provided, only their clones are
+considered for removal, including those with unwanted labels.
+
+Clones with uncommitted changes, unpushed commits or stashes are never removed. With
+'--archive', clones are moved to the given directory instead of being removed.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			archive, _ := cmd.Flags().GetString("archive")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+			var candidates mapset.Set[string]
+			if len(args) > 0 {
+				candidates = catalog.RepositoryListAll(args...)
+			}
+
+			return Prune(catalog.RepositoryList("~all"), candidates, archive, dryRun)
+		},
+	}
+
+	pruneCmd.Flags().String("archive", "", "move pruned clones to this directory instead of removing them")
+	pruneCmd.Flags().Bool("dry-run", false, "list the clones which would be pruned without changing anything")
+
+	return pruneCmd
+}
+
+// Prune removes each local clone in the workspace which doesn't belong to one of the
+// repositories to keep, or moves it to the archive directory if one is provided. If the
+// set of candidates isn't nil, only clones of those repositories are considered. Clones
+// containing local work are only ever archived. With dryRun, the clones are only listed.
+func Prune(keep, candidates mapset.Set[string], archive string, dryRun bool) error {
+	// an empty catalog most likely failed to load, so nothing can be considered stale
+	if len(catalog.Catalog) == 0 {
+		return fmt.Errorf("the repository catalog is empty - refusing to prune")
+	}
+
+	// clones are matched by path, since names are only unique within a project
+	keepPaths := mapset.NewSet[string]()
+
+	for name := range keep.Iter() {
+		path, err := utils.ClonePath(name)
+		if err != nil {
+			return err
+		}
+
+		keepPaths.Add(path)
+	}
+
+	clones, err := utils.WorkspaceClones()
+	if err != nil {
+		return err
+	}
+
+	var failed int
+
+	for _, clone := range clones {
+		name, path := clone.Name, clone.Path
+
+		if keepPaths.Contains(path) {
+			continue
+		}
+
+		if candidates != nil && !candidates.Contains(name) && !candidates.Contains(clone.Project+"/"+name) {
+			continue
+		}
+
+		if dryRun {
+			fmt.Printf("Stale clone: %s\n", path)
+			continue
+		}
+
+		if archive != "" {
+			dest, err := archiveClone(path, archive)
+			if err != nil {
+				fmt.Printf("ERROR: %s: %v\n", name, err)
+				failed++
+
+				continue
+			}
+
+			fmt.Printf("Archived: %s -> %s\n", path, dest)
+
+			continue
+		}
+
+		if err := utils.ValidateDisposable(path); err != nil {
+			fmt.Printf("ERROR: not removing %s: %v\n", name, err)
+			failed++
+
+			continue
+		}
+
+		if err := os.RemoveAll(path); err != nil {
+			fmt.Printf("ERROR: %s: %v\n", name, err)
+			failed++
+
+			continue
+		}
+
+		fmt.Printf("Removed: %s\n", path)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to prune %d clones", failed)
+	}
+
+	return nil
+}
+
+// archiveClone moves the clone into the archive directory, never overwriting an earlier archive.
+// Clones with linked worktrees aren't archived, since the worktrees would be left behind.
+func archiveClone(path, archive string) (string, error) {
+	worktrees, err := utils.LinkedWorktrees(path)
+	if err != nil {
+		return "", err
+	}
+
+	if len(worktrees) > 0 {
+		return "", fmt.Errorf("%s has linked worktrees", path)
+	}
+
+	if err := os.MkdirAll(archive, 0755); err != nil {
+		return "", err
+	}
+
+	dest := filepath.Join(archive, filepath.Base(path))
+	if _, err := os.Stat(dest); err == nil {
+		dest = fmt.Sprintf("%s-%s", dest, time.Now().Format("20060102-150405"))
+	}
+
+	return dest, os.Rename(path, dest)
+}
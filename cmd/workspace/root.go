@@ -0,0 +1,20 @@
+package workspace
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// Cmd configures the root workspace command along with all subcommands and flags
+func Cmd() *cobra.Command {
+	rootCmd := &cobra.Command{
+		Use:   "workspace [cmd]",
+		Short: "Manage the local workspace of repository clones",
+	}
+
+	rootCmd.AddCommand(
+		addMigrateCmd(),
+		addPruneCmd(),
+	)
+
+	return rootCmd
+}
@@ -2,10 +2,12 @@ package config
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"text/template"
 
 	"github.com/spf13/viper"
 )
@@ -17,13 +19,26 @@ const (
 
 	EnvGopath = "gopath"
 
+	WorkspaceRoot     = "workspace.root"
+	WorkspaceTemplate = "workspace.template"
+	WorkspaceProjects = "workspace.projects"
+
 	GitUser      = "git.user"
 	GitHost      = "git.host"
 	GitProject   = "git.project"
 	SourceBranch = "git.default-branch"
 
+	CloneProtocol     = "git.clone.protocol"
+	CloneHTTPSUser    = "git.clone.https-user"
+	CloneDepth        = "git.clone.depth"
+	CloneFilter       = "git.clone.filter"
+	CloneSingleBranch = "git.clone.single-branch"
+	CloneReference    = "git.clone.reference"
+
 	// User, Host, Project, Repo
 	CloneSSHURLTmpl = "ssh://%s@%s/%s/%s.git"
+	// Host, Project, Repo
+	CloneHTTPSURLTmpl = "https://%s/scm/%s/%s.git"
 
 	SortRepos        = "repos.sort"
 	RepoAliases      = "repos.aliases"
@@ -32,16 +47,26 @@ const (
 	DefaultReviewers = "repos.reviewers"
 	CatalogCacheFile = "repos.cache.filename"
 	CatalogCacheTTL  = "repos.cache.ttl"
+	CatalogSource    = "repos.catalog.source"
+	CatalogManifest  = "repos.catalog.manifest"
 
 	CommitAmend   = "commit.amend"
 	CommitMessage = "commit.message"
 
 	Branch    = "branch"
+	Worktree  = "worktree"
 	Reviewers = "reviewers"
 	AuthToken = "auth-token"
 	UseSync   = "sync"
+	Offline   = "offline"
+	Quiet     = "quiet"
+
+	BuildOrder   = "build.order"
+	BuildSystems = "build.systems"
+	TestCommand  = "test.command"
 
 	ChannelBuffer = "channels.buffer-size"
+	MaxParallel   = "channels.max-parallel"
 
 	// Bitbucket v1 API PR template - Host, Project, Repo
 	ApiPathTmpl = "https://%s/rest/api/1.0/projects/%s/repos/%s/pull-requests"
@@ -67,20 +92,29 @@ const (
 	}`
 )
 
-// Init reads in config file and ENV variables if set.
-func Init() {
+// Init reads in config file and ENV variables if set, and returns an error if the
+// resulting configuration is unusable.
+func Init() error {
 	// Default user for SSH clone.
 	viper.SetDefault(GitUser, "git")
 
+	// Default protocol and user for clone, where the HTTPS password is the auth token.
+	viper.SetDefault(CloneProtocol, "ssh")
+	viper.SetDefault(CloneHTTPSUser, os.Getenv("USER"))
+
 	viper.SetDefault(SourceBranch, "develop")
 	viper.SetDefault(SortRepos, true)
 	viper.SetDefault(SkipUnwanted, true)
 	viper.SetDefault(UnwantedLabels, []string{"deprecated", "poc"})
 	viper.SetDefault(UseSync, false)
+	viper.SetDefault(Offline, false)
 	viper.SetDefault(CatalogCacheFile, ".catalog")
 	viper.SetDefault(CatalogCacheTTL, "24h")
+	viper.SetDefault(CatalogSource, "bitbucket")
+	viper.SetDefault(CatalogManifest, "batch-tool-manifest.yaml")
 
 	viper.SetDefault(ChannelBuffer, 100)
+	// MaxParallel is left unset, which is unlimited, so that commands can apply their own default
 
 	// default reviewers in the form `repo: [reviewers...]`
 	viper.SetDefault(DefaultReviewers, map[string][]string{})
@@ -88,9 +122,44 @@ func Init() {
 	// aliases in the form `alias: [repos...]`
 	viper.SetDefault(RepoAliases, map[string][]string{})
 
-	if gopath, err := exec.Command("go", "env", "GOPATH").Output(); err == nil {
-		viper.SetDefault(EnvGopath, strings.TrimSpace(string(gopath)))
-	}
+	// build systems are detected in order by their marker files, and each logical target
+	// is mapped to a command template (with "*" matching any target)
+	viper.SetDefault(BuildOrder, []string{"make", "mage", "npm", "go"})
+	viper.SetDefault(BuildSystems, map[string]interface{}{
+		"make": map[string]interface{}{
+			"files":   []string{"Makefile", "makefile", "GNUmakefile"},
+			"check":   "make -n {{.Target}}",
+			"targets": map[string]interface{}{"*": "make {{.Target}}"},
+		},
+		"mage": map[string]interface{}{
+			"files":   []string{"magefile.go", "magefiles"},
+			"check":   "mage -h {{.Target}}",
+			"targets": map[string]interface{}{"*": "mage {{.Target}}"},
+		},
+		"npm": map[string]interface{}{
+			"files":   []string{"package.json"},
+			"check":   `node -e "process.exit(require('./package.json').scripts?.['{{.Target}}'] ? 0 : 1)"`,
+			"targets": map[string]interface{}{"*": "npm run {{.Target}}"},
+		},
+		"go": map[string]interface{}{
+			"files": []string{"go.mod"},
+			"targets": map[string]interface{}{
+				"format": "gofmt -l -w .",
+				"lint":   "go vet ./...",
+				"test":   "go test ./...",
+				"build":  "go build ./...",
+			},
+		},
+	})
+
+	// the test command must produce the event stream of 'go test -json'
+	viper.SetDefault(TestCommand, "go test -json ./...")
+
+	// repositories are laid out as in a GOPATH unless configured otherwise
+	viper.SetDefault(WorkspaceTemplate, "{{.Root}}/{{.Host}}/{{.Project}}/{{.Name}}")
+
+	// per-project layout overrides in the form `project: {root: ..., template: ...}`
+	viper.SetDefault(WorkspaceProjects, map[string]interface{}{})
 
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_", "-", "_"))
 	viper.AutomaticEnv() // read in environment variables that match
@@ -124,7 +193,108 @@ func Init() {
 	}
 
 	// If a config file is found, read it in.
-	if err := viper.ReadInConfig(); err == nil {
+	if err := viper.ReadInConfig(); err == nil && !viper.GetBool(Quiet) {
 		fmt.Printf("Using config file: %v\n\n", viper.ConfigFileUsed())
 	}
+
+	// Only fall back to the GOPATH if no workspace root has been configured
+	if !viper.IsSet(WorkspaceRoot) {
+		viper.SetDefault(WorkspaceRoot, defaultWorkspaceRoot())
+	}
+
+	return parseWorkspaceTemplates()
+}
+
+// defaultWorkspaceRoot returns the source directory of the GOPATH
+func defaultWorkspaceRoot() string {
+	if gopath := viper.GetString(EnvGopath); gopath != "" {
+		return filepath.Join(gopath, "src")
+	}
+
+	if gopath, err := exec.Command("go", "env", "GOPATH").Output(); err == nil {
+		return filepath.Join(strings.TrimSpace(string(gopath)), "src")
+	}
+
+	home, _ := os.UserHomeDir()
+
+	return filepath.Join(home, "go", "src")
+}
+
+// workspaceTemplates holds the configured workspace path templates, keyed by their text
+var workspaceTemplates = make(map[string]*template.Template)
+
+// parseWorkspaceTemplates parses every configured path template, returning an error
+// if any of them can't be rendered
+func parseWorkspaceTemplates() error {
+	templates := map[string]string{WorkspaceTemplate: viper.GetString(WorkspaceTemplate)}
+
+	for project := range viper.GetStringMap(WorkspaceProjects) {
+		key := fmt.Sprintf("%s.%s.template", WorkspaceProjects, project)
+		if tmpl := viper.GetString(key); tmpl != "" {
+			templates[key] = tmpl
+		}
+	}
+
+	for key, text := range templates {
+		tmpl, err := ParseTemplate(text)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", key, err)
+		}
+
+		workspaceTemplates[text] = tmpl
+	}
+
+	return nil
+}
+
+// LookupTemplate returns the parsed workspace path template with the given text, which
+// is only parsed again if it wasn't part of the configuration when it was loaded
+func LookupTemplate(text string) (*template.Template, error) {
+	if tmpl, ok := workspaceTemplates[text]; ok {
+		return tmpl, nil
+	}
+
+	return ParseTemplate(text)
+}
+
+// ParseTemplate parses the workspace path template, returning an error if it can't be rendered
+func ParseTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("workspace").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tmpl.Execute(io.Discard, WorkspaceFields{}); err != nil {
+		return nil, err
+	}
+
+	return tmpl, nil
+}
+
+// WorkspaceFields are available to workspace path templates
+type WorkspaceFields struct {
+	Root    string
+	Host    string
+	Project string
+	Name    string
+}
+
+// Save writes the given setting to the config file currently in use, leaving
+// all other settings in the file untouched, and applies it to the running config.
+func Save(key string, value interface{}) error {
+	if viper.ConfigFileUsed() == "" {
+		return fmt.Errorf("no config file in use - provide one with --config")
+	}
+
+	v := viper.New()
+	v.SetConfigFile(viper.ConfigFileUsed())
+
+	if err := v.ReadInConfig(); err != nil {
+		return err
+	}
+
+	v.Set(key, value)
+	viper.Set(key, value)
+
+	return v.WriteConfig()
 }
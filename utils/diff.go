@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// Diff returns a unified diff between the old and new content of the file at
+// the given (repository-relative) path, or an empty string if they are equal. A nil
+// old content is a new file, which is compared against /dev/null.
+func Diff(path string, old, new []byte) (string, error) {
+	if !filepath.IsLocal(path) {
+		return "", fmt.Errorf("invalid path %q - must be within the repository", path)
+	}
+
+	dir, err := os.MkdirTemp("", "batch-tool-diff")
+	if err != nil {
+		return "", err
+	}
+
+	defer os.RemoveAll(dir)
+
+	files := map[string][]byte{"b": new}
+	oldPath := "/dev/null"
+
+	if old != nil {
+		files["a"] = old
+		oldPath = filepath.Join("a", path)
+	}
+
+	// the content is written as a/<path> and b/<path> so the diff headers match git
+	for prefix, content := range files {
+		file := filepath.Join(dir, prefix, path)
+
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return "", err
+		}
+
+		if err := os.WriteFile(file, content, 0644); err != nil {
+			return "", err
+		}
+	}
+
+	cmd := exec.Command("git", "diff", "--no-index", "--no-prefix", "--no-color", "--",
+		oldPath, filepath.Join("b", path))
+	cmd.Dir = dir
+
+	output, err := cmd.Output()
+	if err != nil {
+		// git diff exits with status 1 when the files differ
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+			return "", fmt.Errorf("%w: %s", err, output)
+		}
+	}
+
+	return string(output), nil
+}
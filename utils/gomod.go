@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// GoMod is the content of a go.mod file, as reported by 'go mod edit -json'
+type GoMod struct {
+	Module struct {
+		Path string
+	}
+	Go      string
+	Require []GoModRequire
+	Replace []GoModReplace
+}
+
+// GoModRequire is a single module requirement of a go.mod file
+type GoModRequire struct {
+	Path     string
+	Version  string
+	Indirect bool
+}
+
+// GoModReplace is a single replace directive of a go.mod file
+type GoModReplace struct {
+	Old GoModule
+	New GoModule
+}
+
+// GoModule is a module path with an optional version
+type GoModule struct {
+	Path    string
+	Version string
+}
+
+// ReadGoMod parses the go.mod file in the given directory, or returns nil if it doesn't exist
+func ReadGoMod(dir string) (*GoMod, error) {
+	if _, err := os.Stat(filepath.Join(dir, "go.mod")); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	// reading go.mod must never download the toolchain requested by the module
+	cmd := exec.Command("go", "mod", "edit", "-json", "go.mod")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOTOOLCHAIN=local", "GOWORK=off", "GOFLAGS=")
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	var mod GoMod
+	if err := json.Unmarshal(output, &mod); err != nil {
+		return nil, err
+	}
+
+	return &mod, nil
+}
+
+// Requires returns the required version of the module, or an empty string if it isn't required
+func (mod *GoMod) Requires(module string) string {
+	for _, req := range mod.Require {
+		if req.Path == module {
+			return req.Version
+		}
+	}
+
+	return ""
+}
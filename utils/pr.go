@@ -1,7 +1,9 @@
 package utils
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"strings"
 
 	"github.com/spf13/viper"
@@ -49,6 +51,25 @@ func (pr PR) Version() int {
 	return 0
 }
 
+// State of the PR (OPEN, DECLINED or MERGED)
+func (pr PR) State() string {
+	if state, ok := pr["state"]; ok {
+		return state.(string)
+	}
+
+	return ""
+}
+
+// LatestCommit of the source branch of the PR
+func (pr PR) LatestCommit() string {
+	if ref, ok := pr["fromRef"].(map[string]interface{}); ok {
+		commit, _ := ref["latestCommit"].(string)
+		return commit
+	}
+
+	return ""
+}
+
 // AddReviewers appends the given list of reviewers to the PR
 func (pr PR) AddReviewers(reviewers []string) {
 	for _, rev := range reviewers {
@@ -75,3 +96,65 @@ func (pr PR) GetReviewers() []string {
 
 	return output
 }
+
+// Approved returns true if any reviewer has approved the PR
+func (pr PR) Approved() bool {
+	revs, _ := pr["reviewers"].([]interface{})
+
+	for _, rev := range revs {
+		if status, _ := rev.(map[string]interface{})["status"].(string); status == "APPROVED" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetPR fetches the most recent open outgoing pull request for the given branch, or
+// returns nil if the branch has no open pull requests.
+func GetPR(name, branch string) (PR, error) {
+	prs, err := GetPRs(name, branch, "")
+	if err != nil || len(prs) == 0 {
+		return nil, err
+	}
+
+	// return the first PR in the results (this will be the most recent)
+	return prs[0], nil
+}
+
+// GetPRs fetches the outgoing pull requests for the given branch, most recent first,
+// filtered by state (OPEN, DECLINED, MERGED or ALL). The API defaults to OPEN.
+func GetPRs(name, branch, state string) ([]PR, error) {
+	path := fmt.Sprintf("%s?direction=outgoing&at=refs/heads/%s", ApiPath(name), branch)
+	if state != "" {
+		path += "&state=" + state
+	}
+
+	request, err := http.NewRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", viper.GetString(config.AuthToken)))
+	request.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode > 399 {
+		return nil, fmt.Errorf("error %d fetching pull requests for %s", resp.StatusCode, branch)
+	}
+
+	// results will be returned in an array property called "values"
+	raw := struct {
+		Values []PR `json:"values"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	return raw.Values, nil
+}
@@ -0,0 +1,181 @@
+package utils
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+
+	"github.com/ryclarke/cisco-batch-tool/config"
+)
+
+// Clone is a primary repository clone found in the workspace
+type Clone struct {
+	Project string
+	Name    string
+	Path    string
+}
+
+// placeholders rendered into path templates to locate the project and name elements
+const (
+	projectPlaceholder = "\x00project\x00"
+	namePlaceholder    = "\x00name\x00"
+)
+
+// WorkspaceClones walks the workspace root(s) and returns every primary clone located
+// where the workspace layout of its project expects it, sorted by path.
+func WorkspaceClones() ([]Clone, error) {
+	// the global layout matches any project, and each override matches its own project
+	projects := []string{""}
+	for project := range viper.GetStringMap(config.WorkspaceProjects) {
+		projects = append(projects, project)
+	}
+
+	found := make(map[string]Clone)
+
+	for _, project := range projects {
+		clones, err := scanLayout(project)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, clone := range clones {
+			found[clone.Path] = clone
+		}
+	}
+
+	clones := make([]Clone, 0, len(found))
+	for _, clone := range found {
+		clones = append(clones, clone)
+	}
+
+	sort.Slice(clones, func(i, j int) bool { return clones[i].Path < clones[j].Path })
+
+	return clones, nil
+}
+
+// scanLayout finds the clones matching the layout of the project, or of any project if empty
+func scanLayout(project string) ([]Clone, error) {
+	host := viper.GetString(config.GitHost)
+	layout, err := ProjectLayout(project)
+	if err != nil {
+		return nil, err
+	}
+
+	pattern := project
+	if pattern == "" {
+		pattern = projectPlaceholder
+	}
+
+	pathPattern, err := layout.Path(host, pattern, namePlaceholder)
+	if err != nil {
+		return nil, err
+	}
+
+	rel, err := filepath.Rel(layout.Root, pathPattern)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, nil // the template doesn't place repositories under the root
+	}
+
+	parts := strings.Split(rel, string(filepath.Separator))
+
+	var clones []Clone
+
+	err = filepath.WalkDir(layout.Root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if path == layout.Root && os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+
+			return err
+		}
+
+		if !entry.IsDir() || path == layout.Root {
+			return nil
+		}
+
+		rel, err := filepath.Rel(layout.Root, path)
+		if err != nil {
+			return err
+		}
+
+		elems := strings.Split(rel, string(filepath.Separator))
+		depth := len(elems) - 1
+
+		if _, ok := matchElement(parts[depth], elems[depth]); !ok {
+			return filepath.SkipDir
+		}
+
+		if depth < len(parts)-1 {
+			return nil
+		}
+
+		// never descend into a repository, matching or not
+		if info, err := os.Stat(filepath.Join(path, ".git")); err != nil || !info.IsDir() {
+			return filepath.SkipDir
+		}
+
+		clone := Clone{Project: project, Path: path}
+
+		for i, part := range parts {
+			value, _ := matchElement(part, elems[i])
+
+			switch {
+			case strings.Contains(part, projectPlaceholder):
+				clone.Project = value
+			case strings.Contains(part, namePlaceholder):
+				clone.Name = value
+			}
+		}
+
+		if clone.Project == "" {
+			clone.Project = viper.GetString(config.GitProject)
+		}
+
+		if clone.Name == "" {
+			return filepath.SkipDir
+		}
+
+		// the clone must be where the layout of its own project expects it
+		expected, err := ClonePath(clone.Project + "/" + clone.Name)
+		if err != nil {
+			return err
+		}
+
+		if expected == path {
+			clones = append(clones, clone)
+		}
+
+		return filepath.SkipDir
+	})
+
+	return clones, err
+}
+
+// matchElement matches a path element against an element of a rendered template, which
+// may contain a single placeholder surrounded by literal text, and returns the value of
+// the placeholder (if any)
+func matchElement(part, elem string) (string, bool) {
+	for _, placeholder := range []string{projectPlaceholder, namePlaceholder} {
+		prefix, suffix, ok := strings.Cut(part, placeholder)
+		if !ok {
+			continue
+		}
+
+		// elements combining both placeholders can't be split unambiguously
+		if strings.Contains(suffix, "\x00") || strings.Contains(prefix, "\x00") {
+			return "", false
+		}
+
+		if len(elem) <= len(prefix)+len(suffix) || !strings.HasPrefix(elem, prefix) || !strings.HasSuffix(elem, suffix) {
+			return "", false
+		}
+
+		return elem[len(prefix) : len(elem)-len(suffix)], true
+	}
+
+	return "", part == elem
+}
@@ -0,0 +1,178 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a semantic version, optionally prefixed with "v"
+type Version struct {
+	Prefix     string
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+}
+
+// ParseVersion parses a semantic version such as v1.2.3 or 1.2.3-rc.1. Build
+// metadata is ignored.
+func ParseVersion(version string) (Version, error) {
+	var v Version
+
+	text := version
+	if strings.HasPrefix(text, "v") {
+		v.Prefix, text = "v", text[1:]
+	}
+
+	text, _, _ = strings.Cut(text, "+")
+	text, v.Prerelease, _ = strings.Cut(text, "-")
+
+	parts := strings.Split(text, ".")
+	if len(parts) != 3 {
+		return v, fmt.Errorf("invalid semantic version %q", version)
+	}
+
+	for i, dest := range []*int{&v.Major, &v.Minor, &v.Patch} {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("invalid semantic version %q", version)
+		}
+
+		*dest = n
+	}
+
+	return v, nil
+}
+
+// String formats the version, including its prefix
+func (v Version) String() string {
+	s := fmt.Sprintf("%s%d.%d.%d", v.Prefix, v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+
+	return s
+}
+
+// Bump returns the next major, minor or patch release after the version. A prerelease
+// is bumped to its own release if that release is of the same kind (e.g. a major bump
+// of v2.0.0-rc.1 is v2.0.0, but a major bump of v2.1.0-rc.1 is v3.0.0).
+func (v Version) Bump(part string) (Version, error) {
+	next := Version{Prefix: v.Prefix, Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+	pre := v.Prerelease != ""
+
+	switch part {
+	case "major":
+		if !pre || v.Minor != 0 || v.Patch != 0 {
+			next.Major, next.Minor, next.Patch = v.Major+1, 0, 0
+		}
+	case "minor":
+		if !pre || v.Patch != 0 {
+			next.Minor, next.Patch = v.Minor+1, 0
+		}
+	case "patch":
+		if !pre {
+			next.Patch++
+		}
+	default:
+		return v, fmt.Errorf("invalid version bump %q - must be major, minor or patch", part)
+	}
+
+	return next, nil
+}
+
+// Compare returns -1, 0 or 1 if the version is lower, equal or higher than the other.
+// Prereleases are lower than their release and are otherwise compared by identifier.
+func (v Version) Compare(other Version) int {
+	for _, pair := range [][2]int{{v.Major, other.Major}, {v.Minor, other.Minor}, {v.Patch, other.Patch}} {
+		if pair[0] != pair[1] {
+			if pair[0] < pair[1] {
+				return -1
+			}
+
+			return 1
+		}
+	}
+
+	switch {
+	case v.Prerelease == other.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case other.Prerelease == "":
+		return -1
+	}
+
+	return comparePrerelease(v.Prerelease, other.Prerelease)
+}
+
+// comparePrerelease compares dot-separated prerelease identifiers in order. Numeric
+// identifiers are compared numerically and are lower than alphanumeric identifiers,
+// which are compared lexically. If all else is equal, fewer identifiers are lower.
+func comparePrerelease(a, b string) int {
+	left, right := strings.Split(a, "."), strings.Split(b, ".")
+
+	for i := 0; i < len(left) && i < len(right); i++ {
+		x, xErr := strconv.ParseUint(left[i], 10, 64)
+		y, yErr := strconv.ParseUint(right[i], 10, 64)
+
+		switch {
+		case xErr == nil && yErr == nil:
+			if x != y {
+				if x < y {
+					return -1
+				}
+
+				return 1
+			}
+		case xErr == nil:
+			return -1
+		case yErr == nil:
+			return 1
+		case left[i] != right[i]:
+			if left[i] < right[i] {
+				return -1
+			}
+
+			return 1
+		}
+	}
+
+	switch {
+	case len(left) < len(right):
+		return -1
+	case len(left) > len(right):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// LatestVersion returns the highest semantic version tag of the repository, if any
+func LatestVersion(repo string) (*Version, error) {
+	dir, err := RepoPath(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := GitOutput(dir, "tag", "--list")
+	if err != nil {
+		return nil, err
+	}
+
+	var latest *Version
+
+	for _, tag := range strings.Fields(output) {
+		version, err := ParseVersion(tag)
+		if err != nil {
+			continue
+		}
+
+		if latest == nil || version.Compare(*latest) > 0 {
+			latest = &version
+		}
+	}
+
+	return latest, nil
+}
@@ -0,0 +1,148 @@
+package utils
+
+import "testing"
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    Version
+		wantErr bool
+	}{
+		{"1.2.3", Version{Major: 1, Minor: 2, Patch: 3}, false},
+		{"v1.2.3", Version{Prefix: "v", Major: 1, Minor: 2, Patch: 3}, false},
+		{"v1.2.3-rc.1", Version{Prefix: "v", Major: 1, Minor: 2, Patch: 3, Prerelease: "rc.1"}, false},
+		{"v1.2.3-rc.1+build.5", Version{Prefix: "v", Major: 1, Minor: 2, Patch: 3, Prerelease: "rc.1"}, false},
+		{"v1.2.3+build-5", Version{Prefix: "v", Major: 1, Minor: 2, Patch: 3}, false},
+		{"v1.2", Version{}, true},
+		{"v1.2.x", Version{}, true},
+		{"v1.-2.3", Version{}, true},
+		{"release", Version{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseVersion(tt.version)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseVersion(%q) error = %v, wantErr %v", tt.version, err, tt.wantErr)
+			continue
+		}
+
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("ParseVersion(%q) = %+v, want %+v", tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestVersionBump(t *testing.T) {
+	tests := []struct {
+		version string
+		part    string
+		want    string
+	}{
+		{"v1.2.3", "major", "v2.0.0"},
+		{"v1.2.3", "minor", "v1.3.0"},
+		{"v1.2.3", "patch", "v1.2.4"},
+		{"1.2.3", "patch", "1.2.4"},
+
+		// prereleases are released if the release is of the same kind
+		{"v2.0.0-rc.1", "major", "v2.0.0"},
+		{"v2.1.0-rc.1", "major", "v3.0.0"},
+		{"v2.0.1-rc.1", "major", "v3.0.0"},
+		{"v2.1.0-rc.1", "minor", "v2.1.0"},
+		{"v2.0.0-rc.1", "minor", "v2.0.0"},
+		{"v2.1.1-rc.1", "minor", "v2.2.0"},
+		{"v2.1.1-rc.1", "patch", "v2.1.1"},
+		{"v2.0.0-rc.1", "patch", "v2.0.0"},
+	}
+
+	for _, tt := range tests {
+		version, err := ParseVersion(tt.version)
+		if err != nil {
+			t.Fatalf("ParseVersion(%q) error = %v", tt.version, err)
+		}
+
+		got, err := version.Bump(tt.part)
+		if err != nil {
+			t.Errorf("%s.Bump(%q) error = %v", tt.version, tt.part, err)
+			continue
+		}
+
+		if got.String() != tt.want {
+			t.Errorf("%s.Bump(%q) = %s, want %s", tt.version, tt.part, got, tt.want)
+		}
+	}
+
+	if _, err := (Version{}).Bump("build"); err == nil {
+		t.Errorf("Bump(%q) error = nil, want an error", "build")
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	// ordered as in the semantic versioning spec, with some additions
+	ordered := []string{
+		"1.0.0-0",
+		"1.0.0-2",
+		"1.0.0-10",
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.0.1",
+		"1.1.0-rc.1",
+		"1.1.0",
+		"1.10.0",
+		"2.0.0",
+	}
+
+	for i, a := range ordered {
+		for j, b := range ordered {
+			x, err := ParseVersion(a)
+			if err != nil {
+				t.Fatalf("ParseVersion(%q) error = %v", a, err)
+			}
+
+			y, err := ParseVersion(b)
+			if err != nil {
+				t.Fatalf("ParseVersion(%q) error = %v", b, err)
+			}
+
+			want := 0
+			if i < j {
+				want = -1
+			} else if i > j {
+				want = 1
+			}
+
+			if got := x.Compare(y); got != want {
+				t.Errorf("Compare(%s, %s) = %d, want %d", a, b, got, want)
+			}
+		}
+	}
+}
+
+func TestComparePrerelease(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"rc.1", "rc.1", 0},
+		{"rc.1", "rc.2", -1},
+		{"rc.2", "rc.10", -1},
+		{"rc.10", "rc.2", 1},
+		{"1", "alpha", -1},
+		{"alpha", "1", 1},
+		{"alpha", "beta", -1},
+		{"alpha", "alpha.1", -1},
+		{"alpha.1", "alpha", 1},
+		{"alpha.1", "alpha.beta", -1},
+	}
+
+	for _, tt := range tests {
+		if got := comparePrerelease(tt.a, tt.b); got != tt.want {
+			t.Errorf("comparePrerelease(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
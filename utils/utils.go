@@ -1,10 +1,13 @@
 package utils
 
 import (
+	"bufio"
 	"fmt"
+	"os"
 	"os/exec"
-	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 
@@ -33,6 +36,21 @@ func LookupReviewers(name string) []string {
 	return viper.GetStringMapStringSlice(config.DefaultReviewers)[name]
 }
 
+// repoProjects holds the project of each catalog repository which doesn't belong to the
+// configured project, keyed by repository name
+var repoProjects = make(map[string]string)
+
+// SetRepoProject records the project of the named repository for ParseRepo. Names which
+// already include their project are unaffected.
+func SetRepoProject(name, project string) {
+	if project == "" || project == viper.GetString(config.GitProject) {
+		delete(repoProjects, name)
+		return
+	}
+
+	repoProjects[name] = project
+}
+
 // ParseRepo splits a repo identifier into its component parts
 func ParseRepo(repo string) (host, project, name string) {
 	parts := strings.Split(strings.Trim(repo, "/ "), "/")
@@ -40,6 +58,8 @@ func ParseRepo(repo string) (host, project, name string) {
 
 	if len(parts) > 1 {
 		project = parts[len(parts)-2]
+	} else if p, ok := repoProjects[name]; ok {
+		project = p
 	} else {
 		project = viper.GetString(config.GitProject)
 	}
@@ -53,20 +73,14 @@ func ParseRepo(repo string) (host, project, name string) {
 	return
 }
 
-// RepoPath returns the full repository path for the given name
-func RepoPath(repo string) string {
-	host, project, name := ParseRepo(repo)
-
-	return filepath.Join(
-		viper.GetString(config.EnvGopath),
-		"src", host, project, name,
-	)
-}
-
 // RepoURL returns the repository remote url for the given name
 func RepoURL(repo string) string {
 	host, project, name := ParseRepo(repo)
 
+	if viper.GetString(config.CloneProtocol) == "https" {
+		return fmt.Sprintf(config.CloneHTTPSURLTmpl, host, project, name)
+	}
+
 	return fmt.Sprintf(config.CloneSSHURLTmpl,
 		viper.GetString(config.GitUser),
 		host, project, name,
@@ -91,8 +105,13 @@ func ApiPathID(name string, id int) string {
 func LookupBranch(name string) (string, error) {
 	branch := viper.GetString(config.Branch)
 	if branch == "" {
+		dir, err := RepoPath(name)
+		if err != nil {
+			return "", err
+		}
+
 		cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
-		cmd.Dir = RepoPath(name)
+		cmd.Dir = dir
 
 		output, err := cmd.Output()
 		if err != nil {
@@ -108,8 +127,17 @@ func LookupBranch(name string) (string, error) {
 
 // ValidateBranch returns an error if the current git branch is the source branch
 func ValidateBranch(repo string, ch chan<- string) error {
+	if err := ValidatePath(repo); err != nil {
+		return err
+	}
+
+	dir, err := RepoPath(repo)
+	if err != nil {
+		return err
+	}
+
 	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
-	cmd.Dir = RepoPath(repo)
+	cmd.Dir = dir
 
 	output, err := cmd.Output()
 	if err != nil {
@@ -122,3 +150,40 @@ func ValidateBranch(repo string, ch chan<- string) error {
 
 	return nil
 }
+
+// ParseAge parses a duration which may also be given in days (e.g. 30d) or weeks (e.g. 2w)
+func ParseAge(age string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if value, ok := strings.CutSuffix(age, suffix); ok {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return 0, fmt.Errorf("invalid age %q", age)
+			}
+
+			return time.Duration(n) * unit, nil
+		}
+	}
+
+	return time.ParseDuration(age)
+}
+
+// Confirm prompts the user with the given question until they answer yes or no
+func Confirm(question string) (bool, error) {
+	fmt.Printf("%s ", question)
+
+	var confirm string
+
+	for confirm != "yes" && confirm != "no" {
+		fmt.Printf("[yes/no] ")
+
+		answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil {
+			return false, err
+		}
+
+		// strip the trailing newline and make lower
+		confirm = strings.TrimSpace(strings.ToLower(answer))
+	}
+
+	return confirm == "yes", nil
+}
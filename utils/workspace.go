@@ -0,0 +1,241 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/viper"
+
+	"github.com/ryclarke/cisco-batch-tool/config"
+)
+
+// Layout defines where repositories are cloned within the workspace
+type Layout struct {
+	Root     string
+	Template *template.Template
+}
+
+// ProjectLayout returns the workspace layout for the given project, applying
+// any per-project overrides on top of the global configuration.
+func ProjectLayout(project string) (Layout, error) {
+	layout := Layout{Root: viper.GetString(config.WorkspaceRoot)}
+	text := viper.GetString(config.WorkspaceTemplate)
+
+	override := fmt.Sprintf("%s.%s", config.WorkspaceProjects, strings.ToLower(project))
+
+	if root := viper.GetString(override + ".root"); root != "" {
+		layout.Root = root
+	}
+
+	if tmpl := viper.GetString(override + ".template"); tmpl != "" {
+		text = tmpl
+	}
+
+	// expand a leading tilde, since it isn't handled by the shell when read from config
+	if home, err := os.UserHomeDir(); err == nil && strings.HasPrefix(layout.Root, "~/") {
+		layout.Root = filepath.Join(home, layout.Root[2:])
+	}
+
+	tmpl, err := config.LookupTemplate(text)
+	if err != nil {
+		return layout, fmt.Errorf("invalid workspace template: %w", err)
+	}
+
+	layout.Template = tmpl
+
+	return layout, nil
+}
+
+// Path renders the repository path for the layout
+func (l Layout) Path(host, project, name string) (string, error) {
+	var path strings.Builder
+
+	if err := l.Template.Execute(&path, config.WorkspaceFields{
+		Root:    l.Root,
+		Host:    host,
+		Project: project,
+		Name:    name,
+	}); err != nil {
+		return "", fmt.Errorf("invalid workspace template: %w", err)
+	}
+
+	return filepath.Clean(filepath.FromSlash(path.String())), nil
+}
+
+// ClonePath returns the full path of the primary clone for the given name
+func ClonePath(repo string) (string, error) {
+	host, project, name := ParseRepo(repo)
+
+	layout, err := ProjectLayout(project)
+	if err != nil {
+		return "", err
+	}
+
+	return layout.Path(host, project, name)
+}
+
+// RepoPath returns the full working directory for the given name, which is the
+// worktree of the configured branch if worktrees are in use, or the primary clone.
+func RepoPath(repo string) (string, error) {
+	if branch := viper.GetString(config.Worktree); branch != "" {
+		return WorktreePath(repo, branch)
+	}
+
+	return ClonePath(repo)
+}
+
+// WorktreePath returns the full path of the repository worktree for the given branch,
+// which is a sibling of the primary clone in the form `<name>@<branch>`. Slashes in the
+// branch are percent-encoded (along with percent signs) so that distinct branches never
+// share a worktree.
+func WorktreePath(repo, branch string) (string, error) {
+	path, err := ClonePath(repo)
+	if err != nil {
+		return "", err
+	}
+
+	return path + "@" + worktreeEscaper.Replace(branch), nil
+}
+
+// worktreeEscaper encodes branch names as a single path element
+var worktreeEscaper = strings.NewReplacer("%", "%25", "/", "%2F")
+
+// ValidatePath returns an error if the working directory of the repository is missing
+func ValidatePath(repo string) error {
+	path, err := RepoPath(repo)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if branch := viper.GetString(config.Worktree); branch != "" {
+			return fmt.Errorf("worktree %s not found - create it with `git branch --worktree %s`", path, branch)
+		}
+
+		return fmt.Errorf("repository %s not found", path)
+	}
+
+	return nil
+}
+
+// ProjectPath returns the directory containing the repositories of the given project.
+// This assumes that the repository name is the final element of the path template.
+func ProjectPath(project string) (string, error) {
+	layout, err := ProjectLayout(project)
+	if err != nil {
+		return "", err
+	}
+
+	path, err := layout.Path(viper.GetString(config.GitHost), project, "_")
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Dir(path), nil
+}
+
+// LocalClones returns the names of all primary repository clones in the project directory
+func LocalClones(project string) ([]string, error) {
+	dir, err := ProjectPath(project)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(entries))
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		// only count directories which are the primary clone of a git repository
+		if info, err := os.Stat(filepath.Join(dir, entry.Name(), ".git")); err != nil || !info.IsDir() {
+			continue
+		}
+
+		names = append(names, entry.Name())
+	}
+
+	return names, nil
+}
+
+// ValidateDisposable returns an error if the clone at the given path contains local work
+// which would be lost by removing it: uncommitted changes, unpushed commits, stashes or
+// linked worktrees.
+func ValidateDisposable(path string) error {
+	checks := []struct {
+		args   []string
+		reason string
+	}{
+		{[]string{"status", "--porcelain"}, "uncommitted changes"},
+		{[]string{"log", "--branches", "--not", "--remotes", "--oneline"}, "unpushed commits"},
+		{[]string{"stash", "list"}, "stashed changes"},
+	}
+
+	for _, check := range checks {
+		output, err := GitOutput(path, check.args...)
+		if err != nil {
+			return err
+		}
+
+		if output != "" {
+			return fmt.Errorf("%s has %s", path, check.reason)
+		}
+	}
+
+	worktrees, err := LinkedWorktrees(path)
+	if err != nil {
+		return err
+	}
+
+	if len(worktrees) > 0 {
+		return fmt.Errorf("%s has linked worktrees", path)
+	}
+
+	return nil
+}
+
+// LinkedWorktrees returns the paths of the linked worktrees of the primary clone
+func LinkedWorktrees(path string) ([]string, error) {
+	output, err := GitOutput(path, "worktree", "list", "--porcelain")
+	if err != nil {
+		return nil, err
+	}
+
+	var worktrees []string
+
+	for _, line := range strings.Split(output, "\n") {
+		if worktree, ok := strings.CutPrefix(line, "worktree "); ok {
+			worktrees = append(worktrees, worktree)
+		}
+	}
+
+	// the primary worktree is always listed first
+	if len(worktrees) == 0 {
+		return nil, nil
+	}
+
+	return worktrees[1:], nil
+}
+
+// GitOutput runs git in the given directory and returns its trimmed output
+func GitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}